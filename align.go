@@ -0,0 +1,150 @@
+package main
+
+import "strings"
+
+// opKind is the kind of edit needed to turn an expected word into the
+// word the user typed
+type opKind int
+
+const (
+	opMatch      opKind = iota // input word matches expected word
+	opSubstitute               // input word replaces a different expected word
+	opInsert                   // extra input word with no expected counterpart
+	opDelete                   // expected word missing from the input
+)
+
+// wordOp is a single step of an alignment between input and expected words.
+// Input is empty for opDelete and expected is empty for opInsert. A match
+// may cover several words on either side when a rule accepts one phrase in
+// place of another, e.g. "gonna" for "going to".
+type wordOp struct {
+	kind     opKind
+	input    string
+	expected string
+}
+
+// alignWords aligns words using the default rules
+func alignWords(input, expected []string) []wordOp {
+	return defaultRules.alignWords(input, expected)
+}
+
+// alignWords computes a minimal word-level edit script that turns expected
+// into input, using the rule set to decide whether words are equal. A
+// single missing or extra word only affects that word, not the rest of the
+// line as a positional comparison would.
+func (rs *ruleSet) alignWords(input, expected []string) []wordOp {
+	n, m := len(input), len(expected)
+
+	// phrase returns the length of the input and expected phrases starting at
+	// i and j that match as a whole, or 0, 0 if there are none
+	phrase := func(i, j int) (int, int) {
+		for a := 1; a <= rs.maxPhrase && i+a <= n; a++ {
+			for b := 1; b <= rs.maxPhrase && j+b <= m; b++ {
+				if (a > 1 || b > 1) && rs.phrasesMatch(input[i:i+a], expected[j:j+b]) {
+					return a, b
+				}
+			}
+		}
+		return 0, 0
+	}
+
+	// dist[i][j] is the edit distance between input[i:] and expected[j:]
+	dist := make([][]int, n+1)
+	for i := range dist {
+		dist[i] = make([]int, m+1)
+	}
+	for i := n; i >= 0; i-- {
+		for j := m; j >= 0; j-- {
+			switch {
+			case i == n:
+				dist[i][j] = m - j
+			case j == m:
+				dist[i][j] = n - i
+			default:
+				sub := dist[i+1][j+1]
+				if !rs.wordsMatch(input[i], expected[j]) {
+					sub++
+				}
+				dist[i][j] = min(sub, dist[i+1][j]+1, dist[i][j+1]+1)
+				if a, b := phrase(i, j); a > 0 {
+					dist[i][j] = min(dist[i][j], dist[i+a][j+b])
+				}
+			}
+		}
+	}
+
+	// Walk the table from the start, preferring matches and substitutions so
+	// that words line up positionally whenever the cost is the same
+	ops := make([]wordOp, 0, max(n, m))
+	i, j := 0, 0
+	for i < n || j < m {
+		if i < n && j < m && rs.wordsMatch(input[i], expected[j]) && dist[i][j] == dist[i+1][j+1] {
+			ops = append(ops, wordOp{kind: opMatch, input: input[i], expected: expected[j]})
+			i, j = i+1, j+1
+			continue
+		}
+		if i < n && j < m {
+			if a, b := phrase(i, j); a > 0 && dist[i][j] == dist[i+a][j+b] {
+				ops = append(ops, wordOp{
+					kind:     opMatch,
+					input:    strings.Join(input[i:i+a], " "),
+					expected: strings.Join(expected[j:j+b], " "),
+				})
+				i, j = i+a, j+b
+				continue
+			}
+		}
+
+		switch {
+		case i < n && j < m && dist[i][j] == dist[i+1][j+1]+1:
+			ops = append(ops, wordOp{kind: opSubstitute, input: input[i], expected: expected[j]})
+			i, j = i+1, j+1
+		case j < m && (i == n || dist[i][j] == dist[i][j+1]+1):
+			ops = append(ops, wordOp{kind: opDelete, expected: expected[j]})
+			j++
+		default:
+			ops = append(ops, wordOp{kind: opInsert, input: input[i]})
+			i++
+		}
+	}
+	return ops
+}
+
+// alignLines splits what was typed for a block of lines among the expected
+// lines, ignoring where the user broke the lines. The words are aligned
+// across the whole block, so lines that were merged, split or left out
+// still end up against the expected line they belong to. Extra words go
+// with the line before them.
+func (rs *ruleSet) alignLines(input string, expected []string) []string {
+	var words []string
+	var owner []int // line of each expected word
+	for i, line := range expected {
+		for _, w := range rs.words(lyricText(line)) {
+			words = append(words, w)
+			owner = append(owner, i)
+		}
+	}
+
+	typed := make([][]string, len(expected))
+	line, j := 0, 0
+	for _, op := range rs.alignWords(rs.words(input), words) {
+		if j < len(words) && op.kind != opInsert {
+			line = owner[j]
+		}
+		switch op.kind {
+		case opMatch:
+			j += len(rs.words(op.expected))
+		case opSubstitute, opDelete:
+			j++
+		}
+		if op.input != "" && len(typed) > 0 {
+			typed[line] = append(typed[line], op.input)
+		}
+	}
+
+	lines := make([]string, len(expected))
+	for i, w := range typed {
+		lines[i] = rs.joinWords(w)
+	}
+	return lines
+}
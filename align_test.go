@@ -0,0 +1,69 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAlignWords(t *testing.T) {
+	// ops renders an alignment compactly: "=word", "~input/expected", "+input", "-expected"
+	ops := func(input, expected string) string {
+		var parts []string
+		for _, op := range alignWords(strings.Fields(input), strings.Fields(expected)) {
+			switch op.kind {
+			case opMatch:
+				parts = append(parts, "="+op.expected)
+			case opSubstitute:
+				parts = append(parts, "~"+op.input+"/"+op.expected)
+			case opInsert:
+				parts = append(parts, "+"+op.input)
+			case opDelete:
+				parts = append(parts, "-"+op.expected)
+			}
+		}
+		return strings.Join(parts, " ")
+	}
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		want     string
+	}{
+		{"identical", "up above the world", "up above the world", "=up =above =the =world"},
+		{"missing word", "up above world", "up above the world", "=up =above -the =world"},
+		{"extra word", "up above the whole world", "up above the world", "=up =above =the +whole =world"},
+		{"substituted word", "up above a world", "up above the world", "=up =above ~a/the =world"},
+		{"missing first word", "above the world", "up above the world", "-up =above =the =world"},
+		{"forgiving comparison", "Stayin' alive!", "staying alive", "=staying =alive"},
+		{"empty input", "", "the world", "-the -world"},
+		{"empty expected", "the world", "", "+the +world"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ops(tt.input, tt.expected); got != tt.want {
+				t.Errorf("alignWords(%q, %q) = %q, want %q", tt.input, tt.expected, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatDiff(t *testing.T) {
+	t.Run("missing word only marks that word", func(t *testing.T) {
+		got := formatDiff("like a in the sky", "like a diamond in the sky")
+		if !strings.Contains(got, "[diamond]") {
+			t.Errorf("diff should mark missing word, got %q", got)
+		}
+		if strings.Contains(got, "(") {
+			t.Errorf("diff should not report substitutions, got %q", got)
+		}
+	})
+
+	t.Run("substituted word shows expected", func(t *testing.T) {
+		got := formatDiff("like a ruby in the sky", "like a diamond in the sky")
+		if !strings.Contains(got, "ruby") || !strings.Contains(got, "(diamond)") {
+			t.Errorf("diff should show input and expected word, got %q", got)
+		}
+	})
+}
@@ -0,0 +1,46 @@
+package main
+
+import "strings"
+
+// chained returns true for the modes that show only a neighbouring line as
+// the cue for each line
+func (md mode) chained() bool {
+	return md == modeChain || md == modeReverseChain
+}
+
+// chainCue returns the index in allLines of the cue for line i: the line
+// before it, or the line after it in a reverse chain. The cue comes from
+// the whole song so it's right even when practicing a subset of lines. It
+// returns -1 at the start or end of the song.
+func (m model) chainCue(i int) int {
+	step := -1
+	if m.mode == modeReverseChain {
+		step = 1
+	}
+	idx := m.lineIndex(i) + step
+	// With headers hidden the cue is the nearest line of lyrics
+	for m.hideHeaders && idx >= 0 && idx < len(m.allLines) && isComment(m.allLines[idx]) {
+		idx += step
+	}
+	if idx < 0 || idx >= len(m.allLines) {
+		return -1
+	}
+	return idx
+}
+
+// writeChainCue writes the cue for the current line, which is a section
+// header at the start of a section unless headers are hidden
+func (m model) writeChainCue(b *strings.Builder) {
+	idx := m.chainCue(m.currentLine)
+	switch {
+	case idx < 0 && m.mode == modeReverseChain:
+		b.WriteString(dimStyle.Render("(end of song)"))
+	case idx < 0:
+		b.WriteString(dimStyle.Render("(start of song)"))
+	case isComment(m.allLines[idx]):
+		b.WriteString(headerStyle.Render(headerText(m.allLines[idx])))
+	default:
+		b.WriteString(lyricText(m.allLines[idx]))
+	}
+	b.WriteString("\n")
+}
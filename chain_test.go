@@ -0,0 +1,124 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestChain(t *testing.T) {
+	lines := []string{
+		"# Verse",
+		"Twinkle twinkle little star",
+		"How I wonder what you are",
+		"# Chorus",
+		"Up above the world so high",
+	}
+
+	newChain := func(md mode, section int) model {
+		m := initialModel(metadata{}, lines)
+		m.mode = md
+		m.selectSection(section)
+		m.beginRun()
+		return m
+	}
+
+	submit := func(m model, input string) model {
+		m.input.SetValue(input)
+		newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		return newModel.(model)
+	}
+
+	t.Run("shows only the previous line", func(t *testing.T) {
+		m := newChain(modeChain, -1)
+		m = submit(m, "Twinkle twinkle little star")
+		m = submit(m, "How I wonder what you are")
+
+		view := m.View()
+		if !strings.Contains(view, "Chorus") {
+			t.Errorf("view should show the section header at a section start, got: %s", view)
+		}
+		if strings.Contains(view, "Twinkle") || strings.Contains(view, "How I wonder") {
+			t.Errorf("view should not show earlier lines, got: %s", view)
+		}
+	})
+
+	t.Run("cue is the line before", func(t *testing.T) {
+		m := newChain(modeChain, -1)
+		m = submit(m, "Twinkle twinkle little star")
+
+		view := m.View()
+		if !strings.Contains(view, "Twinkle twinkle little star") {
+			t.Errorf("view should show the previous line as a cue, got: %s", view)
+		}
+		if strings.Contains(view, "✓") {
+			t.Errorf("view should not list results, got: %s", view)
+		}
+	})
+
+	t.Run("reverse chain goes backwards", func(t *testing.T) {
+		m := newChain(modeReverseChain, -1)
+		if m.currentLine != 4 {
+			t.Fatalf("currentLine = %d, want the last line", m.currentLine)
+		}
+		if view := m.View(); !strings.Contains(view, "(end of song)") {
+			t.Errorf("view should show the end of the song, got: %s", view)
+		}
+
+		m = submit(m, "Up above the world so high")
+		if m.currentLine != 2 {
+			t.Fatalf("currentLine = %d, want 2 (skip header)", m.currentLine)
+		}
+		if view := m.View(); !strings.Contains(view, "Chorus") {
+			t.Errorf("view should show the next section header as the cue, got: %s", view)
+		}
+
+		m = submit(m, "How I wonder what you are")
+		if view := m.View(); !strings.Contains(view, "How I wonder what you are") || strings.Contains(view, "Up above") {
+			t.Errorf("view should show only the line after as the cue, got: %s", view)
+		}
+
+		m = submit(m, "wrong")
+		if m.state != stateResult {
+			t.Fatalf("state = %v, want stateResult", m.state)
+		}
+		if !m.results[0] || !m.results[2] || !m.results[4] || m.results[1] {
+			t.Errorf("results = %v, want all but the first line right", m.results)
+		}
+		if view := m.View(); !strings.Contains(view, "Score: 2/3 lines") {
+			t.Errorf("view should show score, got: %s", view)
+		}
+	})
+
+	t.Run("cue comes from the whole song", func(t *testing.T) {
+		m := newChain(modeChain, 1) // Chorus
+		m = submit(m, "wrong")
+		m = press(m, 'r')
+
+		// The retry only has the chorus header and its line, but the cue for
+		// the line is still the header before it
+		if view := m.View(); !strings.Contains(view, "Chorus") {
+			t.Errorf("view should show the header, got: %s", view)
+		}
+
+		m = newChain(modeReverseChain, 0) // Verse
+		if view := m.View(); !strings.Contains(view, "Chorus") {
+			t.Errorf("reverse cue at the end of a section should be the next header, got: %s", view)
+		}
+	})
+
+	t.Run("wrong lines show a diff", func(t *testing.T) {
+		m := newChain(modeChain, 1)
+		m = submit(m, "Up above the world so low")
+		if view := m.View(); !strings.Contains(view, "low") || !strings.Contains(view, "(high)") {
+			t.Errorf("view should show the diff, got: %s", view)
+		}
+	})
+}
+
+// press sends a key press to m
+func press(m model, key rune) model {
+	newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{key}})
+	return newModel.(model)
+}
@@ -0,0 +1,504 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Build information, set by goreleaser with -ldflags "-X main.version=..."
+var (
+	version = "dev"
+	commit  = "none"
+	date    = "unknown"
+)
+
+// usage is the top level help text
+const usage = `Usage: recite [practice] [options] <lyrics-file>
+       recite stats <lyrics-file>
+       recite review <dir>
+       recite list [options] [dir | lyrics-file]
+       recite validate <lyrics-file>...
+       recite version
+
+Run "recite practice --help" for the practice options.
+`
+
+// practiceOptions are the command line options for a practice run. All but
+// --hints and --headers skip the section select screen. They override the
+// recite block of the front matter.
+type practiceOptions struct {
+	section    string   // section names, numbers or ranges, empty or "all" for the whole song
+	mode       string   // mode name, empty to ask
+	shuffle    flagBool // practice the sections in random order
+	once       flagBool // sing repeated sections once
+	strictness string   // overrides the front matter, empty to keep it
+	hints      string   // hint policy, empty to keep the front matter's
+	headers    flagBool // show section headers while typing
+}
+
+// skipsSectionSelect returns true if the options say what to practice.
+// --hints and --headers only change how lines are shown, so they leave the
+// section to be picked.
+func (o practiceOptions) skipsSectionSelect() bool {
+	return o.section != "" || o.mode != "" || o.shuffle != flagUnset || o.once != flagUnset || o.strictness != ""
+}
+
+// run runs the subcommand named by the first argument, or practices a file
+// if it isn't a subcommand
+func run(args []string, stdout io.Writer) error {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, usage)
+		return errors.New("no lyrics file given")
+	}
+
+	switch args[0] {
+	case "-h", "-help", "--help", "help":
+		fmt.Fprint(stdout, usage)
+		return nil
+	case "-version", "--version", "version":
+		fmt.Fprintln(stdout, versionString())
+		return nil
+	case "practice":
+		return runPractice(args[1:])
+	case "stats":
+		return runStats(stdout, args[1:])
+	case "review":
+		return runReview(stdout, args[1:])
+	case "list":
+		return runList(stdout, args[1:])
+	case "validate":
+		return runValidate(stdout, args[1:])
+	}
+	return runPractice(args)
+}
+
+// versionString returns the version of the build, falling back to the
+// module version for builds made with go install
+func versionString() string {
+	if version == "dev" {
+		if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+			return "recite " + info.Main.Version
+		}
+		return "recite dev"
+	}
+	return fmt.Sprintf("recite %s (commit %s, built %s)", version, commit, date)
+}
+
+// parsePracticeArgs parses the practice options and the lyrics file. Options
+// may come before or after the file.
+func parsePracticeArgs(args []string) (string, practiceOptions, error) {
+	var opts practiceOptions
+	var strict bool
+
+	fs := flag.NewFlagSet("practice", flag.ContinueOnError)
+	fs.StringVar(&opts.section, "section", "", "practice these sections, by name, number or range, e.g. \"Verse 1,Chorus\" or 2-4")
+	fs.StringVar(&opts.mode, "mode", "", "practice in this mode, e.g. memory, cloze or first-letter")
+	fs.Var(&opts.shuffle, "shuffle", "practice the sections in random order")
+	fs.Var(&opts.once, "once", "sing repeated sections once, typing just the first line of each repeat")
+	fs.BoolVar(&strict, "strict", false, "require exact text, the same as --strictness strict")
+	fs.StringVar(&opts.strictness, "strictness", "", "how closely lines must match: strict, normal or lenient (overrides the file)")
+	fs.StringVar(&opts.hints, "hints", "", "hints Tab gives: on, word or off (overrides the file)")
+	fs.Var(&opts.headers, "headers", "show section headers while typing, --headers=false to hide them")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: recite practice [options] <lyrics-file>")
+		fmt.Fprintln(fs.Output())
+		fmt.Fprintln(fs.Output(), "Options:")
+		fs.PrintDefaults()
+	}
+
+	// The flag package stops at the first argument that isn't a flag, so
+	// pick out the file and carry on after it
+	var files []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return "", opts, err
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		files = append(files, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+
+	if len(files) != 1 {
+		fs.Usage()
+		return "", opts, errors.New("usage: recite practice [options] <lyrics-file>")
+	}
+	if strict {
+		if opts.strictness != "" && opts.strictness != "strict" {
+			return "", opts, errors.New("--strict conflicts with --strictness " + opts.strictness)
+		}
+		opts.strictness = "strict"
+	}
+	return files[0], opts, nil
+}
+
+// runPractice runs the interactive practice session
+func runPractice(args []string) error {
+	filename, opts, err := parsePracticeArgs(args)
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if filename, err = filepath.Abs(filename); err != nil {
+		return fmt.Errorf("reading file: %w", err)
+	}
+	meta, lines, err := readFile(filename)
+	if err != nil {
+		return fmt.Errorf("reading file: %w", err)
+	}
+	if len(lines) == 0 {
+		return errors.New("file is empty")
+	}
+
+	if opts.strictness != "" {
+		meta.Match.Strictness = opts.strictness
+		if meta.rules, err = meta.Match.ruleSet(meta.Language); err != nil {
+			return err
+		}
+	}
+
+	m := initialModel(meta, lines)
+	m.filename = filename
+	if m.historyPath, err = defaultHistoryPath(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: history disabled: %v\n", err)
+	}
+	if err := m.applyOptions(opts); err != nil {
+		return err
+	}
+
+	_, err = tea.NewProgram(m).Run()
+	return err
+}
+
+// applyOptions sets up the model from the practice options. When the mode
+// is given the run starts straight away.
+func (m *model) applyOptions(opts practiceOptions) error {
+	m.shuffle = opts.shuffle.or(m.shuffle)
+	m.once = opts.once.or(m.once)
+	m.hideHeaders = !opts.headers.or(!m.hideHeaders)
+	if opts.hints != "" {
+		hints, err := parseHints(opts.hints)
+		if err != nil {
+			return err
+		}
+		m.hints = hints
+	}
+	if !opts.skipsSectionSelect() {
+		return nil
+	}
+
+	// Without --section, the file's own section is practiced
+	spec := opts.section
+	if spec == "" {
+		spec = m.meta.Recite.Section
+	}
+	sections, err := m.findSections(spec)
+	if err != nil {
+		return err
+	}
+	m.selected = sections
+	m.sectionPreset = true
+
+	if opts.mode == "" {
+		return nil
+	}
+	md, err := m.findMode(opts.mode)
+	if err != nil {
+		return err
+	}
+	m.chooseMode(md)
+	return nil
+}
+
+// findSections returns the indices of the sections in a comma separated
+// list of section names, numbers and ranges of numbers, e.g.
+// "Verse 1,Chorus" or "2-4", or nil for the whole song
+func (m model) findSections(spec string) ([]int, error) {
+	return findSections(m.sections, spec)
+}
+
+// findSections looks up a list of sections as the --section option takes
+// them, for when there's no model yet
+func findSections(sections []section, spec string) ([]int, error) {
+	if spec == "" || strings.EqualFold(spec, "all") {
+		return nil, nil
+	}
+
+	var found []int
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		from, to, err := sectionRange(sections, part)
+		if err != nil {
+			return nil, err
+		}
+		for i := from; i <= to; i++ {
+			found = append(found, i)
+		}
+	}
+	return found, nil
+}
+
+// sectionRange returns the first and last index of the sections named by
+// part, which is a name, a number or a range of numbers such as "2-4"
+func sectionRange(sections []section, part string) (int, int, error) {
+	number := func(s string) (int, bool, error) {
+		n, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil {
+			return 0, false, nil
+		}
+		if n < 1 || n > len(sections) {
+			return 0, true, fmt.Errorf("no section %d, the file has %d", n, len(sections))
+		}
+		return n - 1, true, nil
+	}
+
+	if n, ok, err := number(part); ok || err != nil {
+		return n, n, err
+	}
+	if a, b, found := strings.Cut(part, "-"); found {
+		from, okFrom, err := number(a)
+		if err != nil {
+			return 0, 0, err
+		}
+		to, okTo, err := number(b)
+		if err != nil {
+			return 0, 0, err
+		}
+		if okFrom && okTo {
+			if from > to {
+				return 0, 0, fmt.Errorf("invalid section range %q", part)
+			}
+			return from, to, nil
+		}
+	}
+
+	for i, sec := range sections {
+		if normalize(sec.name) == normalize(part) || normalize(sectionPath(sections, i)) == normalize(part) {
+			return i, i, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("unknown section %q", part)
+}
+
+// findMode returns the available mode with the given name, ignoring case,
+// spaces and dashes, e.g. "first-letter" for First letter
+func (m model) findMode(name string) (mode, error) {
+	var names []string
+	for _, md := range m.availableModes() {
+		if normalize(md.String()) == normalize(name) {
+			return md, nil
+		}
+		names = append(names, modeFlagName(md))
+	}
+	return 0, fmt.Errorf("unknown mode %q, want one of: %s", name, strings.Join(names, ", "))
+}
+
+// modeFlagName returns the name of a mode as written on the command line
+func modeFlagName(md mode) string {
+	return strings.ReplaceAll(strings.ToLower(md.String()), " ", "-")
+}
+
+// chooseMode sets the mode to practice in and moves on to picking a section,
+// or starts the run if the section was given on the command line
+func (m *model) chooseMode(md mode) tea.Cmd {
+	m.mode = md
+	if !m.sectionPreset {
+		m.state = stateSectionSelect
+		m.sectionList = m.newSectionList()
+		return nil
+	}
+	m.selectSections(m.selected)
+	return m.beginRun()
+}
+
+// runList lists the lyrics files in a directory, or the sections and modes
+// of a single file. Files in a directory can be filtered by their front
+// matter.
+func runList(w io.Writer, args []string) error {
+	var filter libraryFilter
+	fs := flag.NewFlagSet("list", flag.ContinueOnError)
+	fs.StringVar(&filter.artist, "artist", "", "only list files by this artist, or part of the name")
+	fs.StringVar(&filter.album, "album", "", "only list files from this album, or part of the name")
+	fs.StringVar(&filter.tag, "tag", "", "only list files with this tag")
+	fs.StringVar(&filter.language, "language", "", "only list files in this language, e.g. en or ja")
+	fs.StringVar(&filter.years, "year", "", "only list files from this year or range of years, e.g. 1970-1979")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: recite list [options] [dir | lyrics-file]")
+		fmt.Fprintln(fs.Output())
+		fmt.Fprintln(fs.Output(), "Options:")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); errors.Is(err, flag.ErrHelp) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		return errors.New("usage: recite list [options] [dir | lyrics-file]")
+	}
+	path := "."
+	if fs.NArg() == 1 {
+		path = fs.Arg(0)
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if !fi.IsDir() {
+		if filter != (libraryFilter{}) {
+			return errors.New("filters only apply when listing a directory")
+		}
+		return listFile(w, path)
+	}
+
+	files, err := findLyricsFiles(path)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		fmt.Fprintln(w, "No lyrics files found.")
+		return nil
+	}
+	listed := 0
+	for _, file := range files {
+		name, _ := filepath.Rel(path, file)
+		meta, lines, err := readFile(file)
+		if err != nil {
+			if filter == (libraryFilter{}) {
+				fmt.Fprintf(w, "%s\t%s\n", name, redStyle.Render("invalid: "+err.Error()))
+			}
+			continue
+		}
+		if ok, err := filter.matches(meta); err != nil {
+			return err
+		} else if !ok {
+			continue
+		}
+		title := meta.Title
+		if meta.Artist != "" {
+			title += " - " + meta.Artist
+		}
+		fmt.Fprintf(w, "%s\t%s (%s)\n", name, title, plural(len(parseSections(lines)), "section"))
+		listed++
+	}
+	if listed == 0 {
+		fmt.Fprintln(w, "No lyrics files match.")
+	}
+	return nil
+}
+
+// listFile lists the sections and modes of a lyrics file, with the names
+// the --section and --mode options take
+func listFile(w io.Writer, filename string) error {
+	meta, lines, err := readFile(filename)
+	if err != nil {
+		return err
+	}
+	m := initialModel(meta, lines)
+
+	if meta.Title != "" {
+		fmt.Fprintln(w, boldStyle.Render(meta.Title))
+	}
+	if meta.Artist != "" {
+		fmt.Fprintln(w, "by "+meta.Artist)
+	}
+	about := append(meta.details(), meta.extraFields()...)
+	for _, line := range about {
+		fmt.Fprintln(w, line)
+	}
+	if meta.Title != "" || meta.Artist != "" || len(about) > 0 {
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintln(w, "Sections:")
+	for i, sec := range m.sections {
+		n := 0
+		for _, line := range lines[sec.startIdx:sec.endIdx] {
+			if !isComment(line) {
+				n++
+			}
+		}
+		count := plural(n, "line")
+		if first := m.firstOf[i]; first != i {
+			count += fmt.Sprintf(", repeat of %d", first+1)
+		}
+		fmt.Fprintf(w, "  %s%d. %s (%s)\n", strings.Repeat("  ", sec.depth), i+1, sec.name, count)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Modes:")
+	for _, md := range m.availableModes() {
+		fmt.Fprintf(w, "  %s\n", modeFlagName(md))
+	}
+	return nil
+}
+
+// runValidate checks that lyrics files can be read and have lyrics in every
+// section, reporting each problem found
+func runValidate(w io.Writer, args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: recite validate <lyrics-file>...")
+	}
+
+	invalid := 0
+	for _, file := range args {
+		problems, warnings := validateFile(file)
+		for _, warning := range warnings {
+			fmt.Fprintf(w, "%s: warning: %s\n", file, warning)
+		}
+		if len(problems) == 0 {
+			fmt.Fprintf(w, "%s: ok\n", file)
+			continue
+		}
+		invalid++
+		for _, p := range problems {
+			fmt.Fprintf(w, "%s: %s\n", file, p)
+		}
+	}
+	if invalid > 0 {
+		return fmt.Errorf("%d of %d files invalid", invalid, len(args))
+	}
+	return nil
+}
+
+// validateFile returns the problems found in a lyrics file, which make it
+// invalid, and the warnings, which don't
+func validateFile(filename string) (problems, warnings []string) {
+	meta, lines, err := readFile(filename)
+	if err != nil {
+		return []string{err.Error()}, nil
+	}
+	if _, err := parseLanguage(meta.Language); err != nil {
+		warnings = append(warnings, fmt.Sprintf("%v, want a tag such as en or ja, matching as if no language was set", err))
+	}
+	if len(lines) == 0 {
+		return []string{"no lyrics"}, warnings
+	}
+
+	sections := parseSections(lines)
+	for s, sec := range sections {
+		empty := true
+		for _, t := range subtree(sections, s) {
+			for _, line := range lines[sections[t].startIdx:sections[t].endIdx] {
+				if !isComment(line) {
+					empty = false
+				}
+			}
+		}
+		if empty {
+			problems = append(problems, fmt.Sprintf("section %q has no lines", sec.name))
+		}
+	}
+	return problems, warnings
+}
@@ -0,0 +1,360 @@
+package main
+
+import (
+	"bytes"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParsePracticeArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		file    string
+		opts    practiceOptions
+		wantErr bool
+	}{
+		{"file only", []string{"song.txt"}, "song.txt", practiceOptions{}, false},
+		{"options before the file", []string{"--mode", "memory", "--section=Chorus", "song.txt"}, "song.txt", practiceOptions{mode: "memory", section: "Chorus"}, false},
+		{"options after the file", []string{"song.txt", "--shuffle", "-mode", "cloze"}, "song.txt", practiceOptions{mode: "cloze", shuffle: flagTrue}, false},
+		{"once", []string{"song.txt", "--once"}, "song.txt", practiceOptions{once: flagTrue}, false},
+		{"turn off a file's shuffle", []string{"--shuffle=false", "song.txt"}, "song.txt", practiceOptions{shuffle: flagFalse}, false},
+		{"hints and headers", []string{"--hints", "word", "--headers=false", "song.txt"}, "song.txt", practiceOptions{hints: "word", headers: flagFalse}, false},
+		{"not a boolean", []string{"--shuffle=maybe", "song.txt"}, "", practiceOptions{}, true},
+		{"strict", []string{"--strict", "song.txt"}, "song.txt", practiceOptions{strictness: "strict"}, false},
+		{"strictness", []string{"--strictness", "lenient", "song.txt"}, "song.txt", practiceOptions{strictness: "lenient"}, false},
+		{"strict conflicts with strictness", []string{"--strict", "--strictness", "lenient", "song.txt"}, "", practiceOptions{}, true},
+		{"no file", []string{"--shuffle"}, "", practiceOptions{}, true},
+		{"two files", []string{"a.txt", "b.txt"}, "", practiceOptions{}, true},
+		{"unknown flag", []string{"--loud", "song.txt"}, "", practiceOptions{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, opts, err := parsePracticeArgs(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parsePracticeArgs(%q) error = %v, wantErr %v", tt.args, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if file != tt.file || opts != tt.opts {
+				t.Errorf("parsePracticeArgs(%q) = %q, %+v, want %q, %+v", tt.args, file, opts, tt.file, tt.opts)
+			}
+		})
+	}
+}
+
+func TestApplyOptions(t *testing.T) {
+	lines := []string{
+		"# Verse",
+		"Twinkle twinkle little star",
+		"How I wonder what you are",
+		"# Chorus",
+		"Up above the world so high",
+		"# Bridge",
+		"Like a diamond in the sky",
+	}
+
+	apply := func(t *testing.T, opts practiceOptions) model {
+		t.Helper()
+		m := initialModel(metadata{}, lines)
+		m.rand = rand.New(rand.NewSource(1))
+		if err := m.applyOptions(opts); err != nil {
+			t.Fatalf("applyOptions error: %v", err)
+		}
+		return m
+	}
+
+	t.Run("no options asks for everything", func(t *testing.T) {
+		m := apply(t, practiceOptions{})
+		m = press(m, '2')
+		if m.state != stateSectionSelect {
+			t.Errorf("state = %v, want stateSectionSelect", m.state)
+		}
+	})
+
+	t.Run("mode and section start the run", func(t *testing.T) {
+		m := apply(t, practiceOptions{mode: "memory", section: "chorus"})
+		if m.state != stateTyping || m.mode != modeMemory {
+			t.Fatalf("state = %v, mode = %v, want typing in memory mode", m.state, m.mode)
+		}
+		if m.sectionName() != "Chorus" || m.currentLine != 1 {
+			t.Errorf("section = %q, line %d, want the first chorus line", m.sectionName(), m.currentLine)
+		}
+	})
+
+	t.Run("section by number", func(t *testing.T) {
+		m := apply(t, practiceOptions{mode: "practice", section: "3"})
+		if m.sectionName() != "Bridge" {
+			t.Errorf("section = %q, want Bridge", m.sectionName())
+		}
+	})
+
+	t.Run("mode names ignore case and dashes", func(t *testing.T) {
+		m := apply(t, practiceOptions{mode: "First-Letter"})
+		if m.mode != modeFirstLetter || m.state != stateTyping {
+			t.Errorf("mode = %v, state = %v, want first letter run", m.mode, m.state)
+		}
+		if m.sectionName() != "All sections" {
+			t.Errorf("section = %q, want the whole song", m.sectionName())
+		}
+	})
+
+	t.Run("section without mode skips the section screen", func(t *testing.T) {
+		m := apply(t, practiceOptions{section: "Verse"})
+		if m.state != stateModeSelect {
+			t.Fatalf("state = %v, want stateModeSelect", m.state)
+		}
+		m = press(m, '2')
+		if m.state != stateTyping || m.sectionName() != "Verse" {
+			t.Errorf("state = %v, section = %q, want typing the verse", m.state, m.sectionName())
+		}
+	})
+
+	t.Run("strictness alone skips the section screen", func(t *testing.T) {
+		m := apply(t, practiceOptions{strictness: "strict"})
+		m = press(m, '1')
+		if m.state != stateTyping || m.sectionName() != "All sections" {
+			t.Errorf("state = %v, section = %q, want typing the whole song", m.state, m.sectionName())
+		}
+	})
+
+	t.Run("display options leave the section screen", func(t *testing.T) {
+		for _, opts := range []practiceOptions{{hints: "word"}, {headers: flagFalse}} {
+			m := apply(t, opts)
+			if m = press(m, '1'); m.state != stateSectionSelect {
+				t.Errorf("%+v: state = %v, want stateSectionSelect", opts, m.state)
+			}
+		}
+	})
+
+	t.Run("file's section with hints on the command line", func(t *testing.T) {
+		path := writeLyrics(t, "---\nrecite:\n  section: Chorus\n---\n"+strings.Join(lines, "\n")+"\n")
+		meta, fileLines, err := readFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		m := initialModel(meta, fileLines)
+		if err := m.applyOptions(practiceOptions{hints: "word"}); err != nil {
+			t.Fatal(err)
+		}
+		m = press(m, '1')
+		if m.state != stateSectionSelect || !strings.Contains(m.View(), "[x] 2. Chorus") {
+			t.Fatalf("state = %v, want the section list with the chorus picked", m.state)
+		}
+		if m.hints != hintsWord {
+			t.Errorf("hints = %v, want word", m.hints)
+		}
+
+		m = initialModel(meta, fileLines)
+		if err := m.applyOptions(practiceOptions{mode: "memory", hints: "word"}); err != nil {
+			t.Fatal(err)
+		}
+		if m.state != stateTyping || m.sectionName() != "Chorus" {
+			t.Errorf("state = %v, section = %q, want typing the file's section", m.state, m.sectionName())
+		}
+	})
+
+	t.Run("shuffle keeps each section together", func(t *testing.T) {
+		m := apply(t, practiceOptions{mode: "practice", shuffle: flagTrue})
+		if len(m.lines) != len(lines) {
+			t.Fatalf("%d lines, want %d", len(m.lines), len(lines))
+		}
+		for i, line := range m.lines {
+			if line != lines[m.lineIndex(i)] {
+				t.Errorf("line %d = %q, want %q", i, line, lines[m.lineIndex(i)])
+			}
+			if isComment(line) && i+1 < len(m.lines) && isComment(m.lines[i+1]) {
+				t.Errorf("section %q is empty", line)
+			}
+		}
+		if !isComment(m.lines[0]) {
+			t.Errorf("first line = %q, want a section header", m.lines[0])
+		}
+	})
+
+	t.Run("shuffle leaves reorder in song order", func(t *testing.T) {
+		m := apply(t, practiceOptions{mode: "reorder", shuffle: flagTrue})
+		if strings.Join(m.lines, "\n") != strings.Join(lines, "\n") {
+			t.Errorf("lines = %q, want song order", m.lines)
+		}
+	})
+
+	t.Run("unknown mode and section", func(t *testing.T) {
+		m := initialModel(metadata{}, lines)
+		if err := m.applyOptions(practiceOptions{mode: "karaoke"}); err == nil || !strings.Contains(err.Error(), "first-letter") {
+			t.Errorf("err = %v, want unknown mode listing the modes", err)
+		}
+		if err := m.applyOptions(practiceOptions{mode: "sing-along"}); err == nil {
+			t.Error("sing-along needs timestamps")
+		}
+		if err := m.applyOptions(practiceOptions{section: "Outro"}); err == nil {
+			t.Error("expected error for unknown section")
+		}
+		if err := m.applyOptions(practiceOptions{section: "4"}); err == nil {
+			t.Error("expected error for section out of range")
+		}
+	})
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	song := write("twinkle.txt", "---\ntitle: Twinkle\nartist: Traditional\nyear: 1806\ntags: [nursery]\ncapo: 2\n---\n# Verse\nTwinkle twinkle\n# Chorus\nUp above\n# Chorus\nUp above\n")
+	empty := write("empty.txt", "# Verse\n# Chorus\nUp above\n")
+	broken := write("broken.txt", "---\nmatch:\n  enable: [telepathy]\n---\nline\n")
+	play := write("play.txt", "# Act 1\n## Scene 1\nTo be\n# Act 2\n## Scene 1\nAlas\n")
+
+	runOut := func(t *testing.T, args ...string) (string, error) {
+		t.Helper()
+		var out bytes.Buffer
+		err := run(args, &out)
+		return out.String(), err
+	}
+
+	t.Run("version", func(t *testing.T) {
+		out, err := runOut(t, "--version")
+		if err != nil || !strings.HasPrefix(out, "recite ") {
+			t.Errorf("out = %q, err = %v, want version", out, err)
+		}
+	})
+
+	t.Run("version from build", func(t *testing.T) {
+		defer func(v, c, d string) { version, commit, date = v, c, d }(version, commit, date)
+		version, commit, date = "1.2.3", "abc123", "2024-01-01"
+		if got := versionString(); got != "recite 1.2.3 (commit abc123, built 2024-01-01)" {
+			t.Errorf("versionString() = %q", got)
+		}
+	})
+
+	t.Run("help", func(t *testing.T) {
+		out, err := runOut(t, "help")
+		if err != nil || !strings.Contains(out, "recite validate") {
+			t.Errorf("out = %q, err = %v, want usage", out, err)
+		}
+	})
+
+	t.Run("list directory", func(t *testing.T) {
+		out, err := runOut(t, "list", dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(out, "twinkle.txt\tTwinkle - Traditional (3 sections)") {
+			t.Errorf("out should list the song, got %q", out)
+		}
+		if !strings.Contains(out, "broken.txt") || !strings.Contains(out, "invalid") {
+			t.Errorf("out should flag the broken file, got %q", out)
+		}
+	})
+
+	t.Run("list directory with filters", func(t *testing.T) {
+		out, err := runOut(t, "list", "--tag", "nursery", "--year", "1800-1899", dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(out, "twinkle.txt") || strings.Contains(out, "play.txt") || strings.Contains(out, "broken.txt") {
+			t.Errorf("out should list just the nursery rhyme, got %q", out)
+		}
+
+		out, err = runOut(t, "list", "--artist", "Mitchell", dir)
+		if err != nil || out != "No lyrics files match.\n" {
+			t.Errorf("out = %q, err = %v, want no matches", out, err)
+		}
+		if _, err := runOut(t, "list", "--year", "soon", dir); err == nil {
+			t.Error("expected error for invalid year")
+		}
+		if _, err := runOut(t, "list", "--tag", "nursery", song); err == nil {
+			t.Error("expected error for filtering a single file")
+		}
+	})
+
+	t.Run("list file", func(t *testing.T) {
+		out, err := runOut(t, "list", song)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range []string{"by Traditional", "1806", "Tags: nursery", "capo: 2", "1. Verse (1 line)", "2. Chorus (1 line)", "3. Chorus (1 line, repeat of 2)", "first-letter", "section-recall"} {
+			if !strings.Contains(out, want) {
+				t.Errorf("out should contain %q, got %q", want, out)
+			}
+		}
+		if strings.Contains(out, "sing-along") {
+			t.Errorf("sing-along needs timestamps, got %q", out)
+		}
+	})
+
+	t.Run("list nested sections", func(t *testing.T) {
+		out, err := runOut(t, "list", play)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range []string{"  1. Act 1 (0 lines)", "    2. Scene 1 (1 line)", "  3. Act 2 (0 lines)", "    4. Scene 1 (1 line)"} {
+			if !strings.Contains(out, want) {
+				t.Errorf("out should contain %q, got %q", want, out)
+			}
+		}
+	})
+
+	t.Run("validate nested sections", func(t *testing.T) {
+		out, err := runOut(t, "validate", play)
+		if err != nil || !strings.Contains(out, play+": ok") {
+			t.Errorf("out = %q, err = %v, want sections with only nested lines to pass", out, err)
+		}
+	})
+
+	t.Run("validate warns about a language that isn't a tag", func(t *testing.T) {
+		english := writeLyrics(t, "---\nlanguage: English\n---\nline\n")
+		out, err := runOut(t, "validate", english)
+		if err != nil || !strings.Contains(out, english+": ok") {
+			t.Errorf("out = %q, err = %v, want the file to pass", out, err)
+		}
+		if !strings.Contains(out, english+`: warning: invalid language "English"`) {
+			t.Errorf("out should warn about the language, got %q", out)
+		}
+	})
+
+	t.Run("validate", func(t *testing.T) {
+		out, err := runOut(t, "validate", song, empty, broken)
+		if err == nil || err.Error() != "2 of 3 files invalid" {
+			t.Errorf("err = %v, want 2 of 3 files invalid", err)
+		}
+		if !strings.Contains(out, song+": ok") {
+			t.Errorf("out should pass the song, got %q", out)
+		}
+		if !strings.Contains(out, `section "Verse" has no lines`) {
+			t.Errorf("out should report the empty section, got %q", out)
+		}
+		if !strings.Contains(out, "invalid YAML front matter") {
+			t.Errorf("out should report the broken front matter, got %q", out)
+		}
+	})
+
+	t.Run("stats and review write to the given writer", func(t *testing.T) {
+		t.Setenv("XDG_DATA_HOME", t.TempDir())
+		out, err := runOut(t, "stats", song)
+		if err != nil || out != "No history yet.\n" {
+			t.Errorf("stats out = %q, err = %v, want no history", out, err)
+		}
+		out, err = runOut(t, "review", t.TempDir())
+		if err != nil || out != "No lyrics files found.\n" {
+			t.Errorf("review out = %q, err = %v, want no files", out, err)
+		}
+	})
+
+	t.Run("practice reports a missing file", func(t *testing.T) {
+		if _, err := runOut(t, "practice", filepath.Join(dir, "missing.txt")); err == nil {
+			t.Error("expected error for missing file")
+		}
+	})
+}
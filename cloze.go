@@ -0,0 +1,75 @@
+package main
+
+import (
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"strings"
+)
+
+// clozeRatios is the fraction of words blanked at each cloze level. The
+// level goes up each time a section is passed without mistakes.
+var clozeRatios = []float64{0.25, 0.5, 1}
+
+// clozeBlanks returns which words of line are blanked at ratio. Words are
+// blanked in a fixed random order per line, so the words blanked at a
+// lower ratio stay blanked as the ratio increases.
+func clozeBlanks(line string, ratio float64) []bool {
+	words := strings.Fields(lyricText(line))
+	blanks := make([]bool, len(words))
+	if len(words) == 0 {
+		return blanks
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte(line))
+	order := rand.New(rand.NewSource(int64(h.Sum64()))).Perm(len(words))
+
+	n := max(int(math.Round(ratio*float64(len(words)))), 1)
+	for _, idx := range order[:min(n, len(words))] {
+		blanks[idx] = true
+	}
+	return blanks
+}
+
+// clozeRatio returns the fraction of words blanked at the current level
+func (m model) clozeRatio() float64 {
+	return clozeRatios[min(m.clozeLevel, len(clozeRatios)-1)]
+}
+
+// clozeAnswer returns the blanked words of line, which is what the user
+// types in cloze mode
+func (m model) clozeAnswer(line string) string {
+	words := strings.Fields(lyricText(line))
+	var answer []string
+	for i, blank := range clozeBlanks(line, m.clozeRatio()) {
+		if blank {
+			answer = append(answer, words[i])
+		}
+	}
+	return strings.Join(answer, " ")
+}
+
+// clozePrompt renders line with its blanked words replaced by underscores
+func (m model) clozePrompt(line string) string {
+	words := strings.Fields(lyricText(line))
+	for i, blank := range clozeBlanks(line, m.clozeRatio()) {
+		if blank {
+			words[i] = strings.Repeat("_", len([]rune(words[i])))
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// passed returns true if every line of the run was correct
+func (m model) passed() bool {
+	score := m.runScore()
+	return score.total > 0 && score.correct == score.total
+}
+
+// advanceCloze moves to the next cloze level after a passed run
+func (m *model) advanceCloze() {
+	if m.mode == modeCloze && m.passed() && m.clozeLevel < len(clozeRatios)-1 {
+		m.clozeLevel++
+	}
+}
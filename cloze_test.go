@@ -0,0 +1,151 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestClozeBlanks(t *testing.T) {
+	const line = "Twinkle twinkle little star how I wonder what you are"
+
+	count := func(blanks []bool) int {
+		n := 0
+		for _, b := range blanks {
+			if b {
+				n++
+			}
+		}
+		return n
+	}
+
+	t.Run("blanks the ratio of words", func(t *testing.T) {
+		for _, tt := range []struct {
+			ratio float64
+			want  int
+		}{{0.25, 3}, {0.5, 5}, {1, 10}} {
+			if got := count(clozeBlanks(line, tt.ratio)); got != tt.want {
+				t.Errorf("clozeBlanks(%v) blanked %d words, want %d", tt.ratio, got, tt.want)
+			}
+		}
+	})
+
+	t.Run("blanks at least one word", func(t *testing.T) {
+		if got := count(clozeBlanks("Hello", 0.25)); got != 1 {
+			t.Errorf("blanked %d words, want 1", got)
+		}
+	})
+
+	t.Run("higher ratios keep earlier blanks", func(t *testing.T) {
+		low, high := clozeBlanks(line, 0.25), clozeBlanks(line, 0.5)
+		for i := range low {
+			if low[i] && !high[i] {
+				t.Errorf("word %d blanked at 25%% but not at 50%%", i)
+			}
+		}
+	})
+
+	t.Run("blanks are stable", func(t *testing.T) {
+		a, b := clozeBlanks(line, 0.5), clozeBlanks(line, 0.5)
+		for i := range a {
+			if a[i] != b[i] {
+				t.Fatal("blanks should be the same for the same line")
+			}
+		}
+	})
+}
+
+func TestClozeMode(t *testing.T) {
+	const line = "Up above the world so high"
+
+	newCloze := func() model {
+		m := initialModel(metadata{}, []string{line})
+		m.mode = modeCloze
+		m.state = stateTyping
+		return m
+	}
+
+	t.Run("prompt matches answer", func(t *testing.T) {
+		m := newCloze()
+		prompt, answer := m.clozePrompt(line), m.clozeAnswer(line)
+
+		words, blanked := strings.Fields(line), strings.Fields(prompt)
+		var missing []string
+		for i := range words {
+			if strings.Trim(blanked[i], "_") == "" {
+				missing = append(missing, words[i])
+			}
+		}
+		if strings.Join(missing, " ") != answer {
+			t.Errorf("answer = %q, want blanked words %q from prompt %q", answer, missing, prompt)
+		}
+	})
+
+	t.Run("view shows the prompt", func(t *testing.T) {
+		m := newCloze()
+		if view := m.View(); !strings.Contains(view, m.clozePrompt(line)) || !strings.Contains(view, "___") {
+			t.Errorf("view should show blanked line, got: %s", view)
+		}
+	})
+
+	t.Run("typing the blanked words is correct", func(t *testing.T) {
+		m := newCloze()
+		m.input.SetValue(m.clozeAnswer(line))
+
+		newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		m = newModel.(model)
+		if !m.results[0] {
+			t.Error("typing the blanked words should be correct")
+		}
+	})
+
+	t.Run("typing the whole line is wrong", func(t *testing.T) {
+		m := newCloze()
+		m.input.SetValue(line)
+
+		newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		m = newModel.(model)
+		if m.results[0] {
+			t.Error("only the blanked words should be typed")
+		}
+	})
+
+	t.Run("passing advances the level on retry", func(t *testing.T) {
+		m := newCloze()
+		m.input.SetValue(m.clozeAnswer(line))
+		newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		m = newModel.(model)
+
+		if view := m.View(); !strings.Contains(view, "Next time 50% of words will be blanked") {
+			t.Errorf("result should announce the next level, got: %s", view)
+		}
+
+		newModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}})
+		m = newModel.(model)
+		if m.clozeLevel != 1 {
+			t.Errorf("clozeLevel = %d, want 1", m.clozeLevel)
+		}
+	})
+
+	t.Run("failing keeps the level", func(t *testing.T) {
+		m := newCloze()
+		m.input.SetValue("wrong")
+		newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		m = newModel.(model)
+
+		newModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}})
+		m = newModel.(model)
+		if m.clozeLevel != 0 {
+			t.Errorf("clozeLevel = %d, want 0", m.clozeLevel)
+		}
+	})
+
+	t.Run("level stops at all words blanked", func(t *testing.T) {
+		m := newCloze()
+		m.clozeLevel = len(clozeRatios) - 1
+		if got := m.clozeAnswer(line); got != line {
+			t.Errorf("answer = %q, want whole line", got)
+		}
+	})
+}
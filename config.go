@@ -0,0 +1,187 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// frontMatterLine is the line of the file the front matter starts on, after
+// the opening "---", for turning YAML line numbers into file line numbers
+const frontMatterLine = 2
+
+// yamlLineRe matches the line number starting an error from the YAML
+// parser, e.g. "line 3: cannot unmarshal"
+var yamlLineRe = regexp.MustCompile(`^line (\d+):`)
+
+// frontMatterError returns an error from parsing the front matter with
+// the YAML parser's line numbers, which count from the opening "---",
+// turned into lines of the file like the errors from the recite block
+func frontMatterError(err error) error {
+	var errs []string
+	var typeErr *yaml.TypeError
+	switch {
+	case errors.As(err, &typeErr):
+		errs = typeErr.Errors
+	case strings.HasPrefix(err.Error(), "yaml: "):
+		errs = []string{strings.TrimPrefix(err.Error(), "yaml: ")}
+	default:
+		return err // already on the file's lines
+	}
+
+	lines := make([]string, len(errs))
+	for i, e := range errs {
+		lines[i] = yamlLineRe.ReplaceAllStringFunc(e, func(s string) string {
+			n, _ := strconv.Atoi(s[len("line ") : len(s)-1])
+			return fmt.Sprintf("line %d:", n+frontMatterLine-1)
+		})
+	}
+	return errors.New(strings.Join(lines, "; "))
+}
+
+// hintPolicy says which hints Tab gives
+type hintPolicy int
+
+const (
+	hintsOn   hintPolicy = iota // the next word, then the whole line
+	hintsWord                   // only the next word
+	hintsOff                    // no hints
+)
+
+// parseHints parses a hint policy name, with "" for the default
+func parseHints(name string) (hintPolicy, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "on":
+		return hintsOn, nil
+	case "word":
+		return hintsWord, nil
+	case "off":
+		return hintsOff, nil
+	}
+	return hintsOn, fmt.Errorf("unknown hints %q, want on, word or off", name)
+}
+
+// practiceConfig is the recite block of the front matter, which sets how a
+// file is practiced. Command line options override it.
+type practiceConfig struct {
+	Section    string     // sections picked when the section list opens, as taken by --section
+	Hints      hintPolicy // which hints Tab gives
+	Strictness string     // how closely lines must match, overriding match.strictness
+	Shuffle    bool       // practice the sections in random order
+	Once       bool       // sing repeated sections once
+	HideHeader bool       // leave section headers out while typing
+
+	sectionLine int // line of the file the section is set on, for errors
+}
+
+// UnmarshalYAML reads the recite block, reporting any problem with the line
+// of the file it's on
+func (c *practiceConfig) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind != yaml.MappingNode {
+		return configError(value, "recite must be a set of options such as \"shuffle: true\"")
+	}
+
+	for i := 0; i+1 < len(value.Content); i += 2 {
+		key, v := value.Content[i], value.Content[i+1]
+		switch key.Value {
+		case "section", "hints", "strictness", "shuffle", "once", "headers":
+		default:
+			return configError(key, "unknown recite option %q, want section, hints, strictness, shuffle, once or headers", key.Value)
+		}
+		if v.Kind != yaml.ScalarNode {
+			return configError(v, "%s must be a single value", key.Value)
+		}
+
+		switch key.Value {
+		case "section":
+			c.Section, c.sectionLine = v.Value, v.Line+frontMatterLine-1
+		case "hints":
+			hints, err := parseHints(v.Value)
+			if err != nil {
+				return configError(v, "%v", err)
+			}
+			c.Hints = hints
+		case "strictness":
+			if _, err := parseStrictness(v.Value); err != nil {
+				return configError(v, "%v", err)
+			}
+			c.Strictness = v.Value
+		default:
+			var on bool
+			if err := v.Decode(&on); err != nil {
+				return configError(v, "%s must be true or false, not %q", key.Value, v.Value)
+			}
+			switch key.Value {
+			case "shuffle":
+				c.Shuffle = on
+			case "once":
+				c.Once = on
+			case "headers":
+				c.HideHeader = !on
+			}
+		}
+	}
+	return nil
+}
+
+// configError returns an error for a problem with a node of the recite
+// block, starting with the line of the file it's on
+func configError(node *yaml.Node, format string, args ...any) error {
+	return fmt.Errorf("line %d: %s", node.Line+frontMatterLine-1, fmt.Sprintf(format, args...))
+}
+
+// checkSection returns an error if the section picked by the recite block
+// isn't in the file
+func (c practiceConfig) checkSection(sections []section) error {
+	if c.Section == "" {
+		return nil
+	}
+	if _, err := findSections(sections, c.Section); err != nil {
+		return fmt.Errorf("line %d: %w", c.sectionLine, err)
+	}
+	return nil
+}
+
+// flagBool is a boolean command line option that remembers whether it was
+// given, so that it can override the front matter either way
+type flagBool int8
+
+const (
+	flagUnset flagBool = iota
+	flagFalse
+	flagTrue
+)
+
+func (b *flagBool) Set(s string) error {
+	on, err := strconv.ParseBool(s)
+	if err != nil {
+		return errors.New("want true or false")
+	}
+	*b = flagFalse
+	if on {
+		*b = flagTrue
+	}
+	return nil
+}
+
+func (b *flagBool) String() string {
+	if b == nil || *b == flagUnset {
+		return ""
+	}
+	return strconv.FormatBool(*b == flagTrue)
+}
+
+// IsBoolFlag lets the option be given without a value, e.g. --shuffle
+func (b *flagBool) IsBoolFlag() bool { return true }
+
+// or returns the option's value, or def if it wasn't given
+func (b flagBool) or(def bool) bool {
+	if b == flagUnset {
+		return def
+	}
+	return b == flagTrue
+}
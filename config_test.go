@@ -0,0 +1,162 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestReadFileConfig(t *testing.T) {
+	lyrics := "# Verse\nTwinkle twinkle little star\n# Chorus\nUp above the world so high\n"
+
+	t.Run("reads every option", func(t *testing.T) {
+		path := writeLyrics(t, "---\ntitle: Twinkle\nrecite:\n  section: Chorus\n  hints: word\n  strictness: strict\n  shuffle: true\n  once: yes\n  headers: false\n---\n"+lyrics)
+		meta, _, err := readFile(path)
+		if err != nil {
+			t.Fatalf("readFile error: %v", err)
+		}
+		c := meta.Recite
+		if c.Section != "Chorus" || c.Hints != hintsWord || !c.Shuffle || !c.Once || !c.HideHeader {
+			t.Errorf("Recite = %+v", c)
+		}
+		if meta.Match.Strictness != "strict" || meta.rules.strictness != strictnessStrict {
+			t.Errorf("strictness = %q, want strict", meta.Match.Strictness)
+		}
+		if _, ok := meta.Extra["recite"]; ok {
+			t.Error("recite should not be kept as an unknown key")
+		}
+	})
+
+	t.Run("no recite block keeps the defaults", func(t *testing.T) {
+		meta, _, err := readFile(writeLyrics(t, "---\ntitle: Twinkle\n---\n"+lyrics))
+		if err != nil {
+			t.Fatalf("readFile error: %v", err)
+		}
+		if meta.Recite != (practiceConfig{}) {
+			t.Errorf("Recite = %+v, want the zero value", meta.Recite)
+		}
+	})
+
+	errTests := []struct {
+		name    string
+		recite  string
+		wantErr string
+	}{
+		{"unknown option", "recite:\n  shuffle: true\n  karaoke: true\n", "line 4: unknown recite option \"karaoke\""},
+		{"unknown hints", "recite:\n  hints: some\n", "line 3: unknown hints \"some\""},
+		{"unknown strictness", "title: Twinkle\nrecite:\n  strictness: picky\n", "line 4: unknown strictness \"picky\""},
+		{"not a boolean", "recite:\n  headers: hidden\n", "line 3: headers must be true or false"},
+		{"list value", "recite:\n  section: [Verse, Chorus]\n", "line 3: section must be a single value"},
+		{"not a mapping", "recite: shuffle\n", "line 2: recite must be a set of options"},
+		{"unknown section", "recite:\n\n  section: Bridge\n", "line 4: unknown section \"Bridge\""},
+		{"wrong type outside the recite block", "title: Twinkle\nmatch:\n  enable: 3\n", "line 4: cannot unmarshal"},
+		{"YAML syntax", "title: Twinkle\nartist: a: b\n", "line 3: mapping values are not allowed"},
+	}
+	for _, tt := range errTests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := readFile(writeLyrics(t, "---\n"+tt.recite+"---\n"+lyrics))
+			if err == nil || !strings.Contains(err.Error(), "invalid YAML front matter: "+tt.wantErr) {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPracticeConfig(t *testing.T) {
+	lines := []string{"# Verse", "Hello world today", "# Chorus", "Up above the world so high"}
+	withConfig := func(c practiceConfig) model {
+		return initialModel(metadata{Recite: c}, lines)
+	}
+	tab := func(m model) model {
+		newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyTab})
+		return newModel.(model)
+	}
+
+	t.Run("word hints stop at the next word", func(t *testing.T) {
+		m := withConfig(practiceConfig{Hints: hintsWord})
+		m.selectSection(-1)
+		m.beginRun()
+		m = tab(tab(m))
+		if m.hint != "Hello" || m.hintLevel != 1 {
+			t.Errorf("hint = %q, level %d, want just the next word", m.hint, m.hintLevel)
+		}
+	})
+
+	t.Run("hints off", func(t *testing.T) {
+		m := withConfig(practiceConfig{Hints: hintsOff})
+		m.selectSection(-1)
+		m.beginRun()
+		if m = tab(m); m.hint != "" || m.hintLevel != 0 {
+			t.Errorf("hint = %q, level %d, want none", m.hint, m.hintLevel)
+		}
+	})
+
+	t.Run("hidden headers", func(t *testing.T) {
+		m := withConfig(practiceConfig{HideHeader: true})
+		m.selectSection(-1)
+		m.beginRun()
+		m.input.SetValue("Hello world today")
+		m.submitLine()
+		if view := m.View(); strings.Contains(view, "Verse") || strings.Contains(view, "Chorus") {
+			t.Errorf("view should leave out headers, got: %s", view)
+		}
+	})
+
+	t.Run("hidden headers in a chain cue the line before", func(t *testing.T) {
+		m := withConfig(practiceConfig{HideHeader: true})
+		m.mode = modeChain
+		m.selectSection(-1)
+		m.beginRun()
+		if got := m.chainCue(3); got != 1 {
+			t.Errorf("chainCue(3) = %d, want 1", got)
+		}
+		if got := m.chainCue(1); got != -1 {
+			t.Errorf("chainCue(1) = %d, want the start of the song", got)
+		}
+	})
+
+	t.Run("section list opens with the file's section picked", func(t *testing.T) {
+		m := withConfig(practiceConfig{Section: "Chorus"})
+		m.chooseMode(modePractice)
+		if view := m.View(); !strings.Contains(view, "[x] 2. Chorus") {
+			t.Errorf("view should show the chorus picked, got: %s", view)
+		}
+		newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		if m = newModel.(model); m.sectionName() != "Chorus" {
+			t.Errorf("section = %q, want Chorus", m.sectionName())
+		}
+	})
+
+	t.Run("shuffle and once from the file", func(t *testing.T) {
+		m := withConfig(practiceConfig{Shuffle: true, Once: true})
+		if !m.shuffle || !m.once {
+			t.Errorf("shuffle = %v, once = %v, want both on", m.shuffle, m.once)
+		}
+	})
+
+	t.Run("command line overrides the file", func(t *testing.T) {
+		m := withConfig(practiceConfig{Shuffle: true, HideHeader: true, Hints: hintsOff})
+		if err := m.applyOptions(practiceOptions{shuffle: flagFalse, headers: flagTrue, hints: "on"}); err != nil {
+			t.Fatal(err)
+		}
+		if m.shuffle || m.hideHeaders || m.hints != hintsOn {
+			t.Errorf("shuffle = %v, hideHeaders = %v, hints = %v, want the command line's", m.shuffle, m.hideHeaders, m.hints)
+		}
+
+		m = withConfig(practiceConfig{Shuffle: true, HideHeader: true})
+		if err := m.applyOptions(practiceOptions{mode: "practice"}); err != nil {
+			t.Fatal(err)
+		}
+		if !m.shuffle || !m.hideHeaders {
+			t.Errorf("shuffle = %v, hideHeaders = %v, want the file's", m.shuffle, m.hideHeaders)
+		}
+	})
+
+	t.Run("unknown hints on the command line", func(t *testing.T) {
+		m := withConfig(practiceConfig{})
+		if err := m.applyOptions(practiceOptions{hints: "lots"}); err == nil {
+			t.Error("expected error for unknown hints")
+		}
+	})
+}
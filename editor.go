@@ -0,0 +1,179 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// cursorStyle highlights the character under the cursor when it isn't at
+// the end of the line
+var cursorStyle = lipgloss.NewStyle().Reverse(true)
+
+// lineEditor is a single line text input. It edits by rune rather than by
+// byte so accented and non-Latin characters are never cut in half.
+type lineEditor struct {
+	value []rune
+	pos   int // cursor position in value
+}
+
+// Value returns the text typed so far
+func (e lineEditor) Value() string {
+	return string(e.value)
+}
+
+// SetValue replaces the text and moves the cursor to the end
+func (e *lineEditor) SetValue(s string) {
+	e.value = []rune(s)
+	e.pos = len(e.value)
+}
+
+// Reset clears the text
+func (e *lineEditor) Reset() {
+	e.SetValue("")
+}
+
+// update applies an editing key and returns true if the text changed.
+// Pasted text is inserted with any line breaks and tabs turned into spaces.
+func (e *lineEditor) update(msg tea.KeyMsg) bool {
+	switch msg.Type {
+	case tea.KeyRunes:
+		if msg.Alt {
+			return false
+		}
+		return e.insert(msg.Runes)
+
+	case tea.KeySpace:
+		return e.insert([]rune{' '})
+
+	case tea.KeyBackspace, tea.KeyCtrlH:
+		if msg.Alt {
+			return e.deleteWord()
+		}
+		if e.pos == 0 {
+			return false
+		}
+		e.splice(e.pos-1, e.pos, nil)
+		return true
+
+	case tea.KeyDelete:
+		if e.pos == len(e.value) {
+			return false
+		}
+		e.splice(e.pos, e.pos+1, nil)
+		return true
+
+	case tea.KeyCtrlW:
+		return e.deleteWord()
+
+	case tea.KeyCtrlU:
+		// Delete from the start of the line to the cursor
+		if e.pos == 0 {
+			return false
+		}
+		e.splice(0, e.pos, nil)
+		return true
+
+	case tea.KeyCtrlK:
+		// Delete from the cursor to the end of the line
+		if e.pos == len(e.value) {
+			return false
+		}
+		e.splice(e.pos, len(e.value), nil)
+		return true
+
+	case tea.KeyLeft, tea.KeyCtrlB:
+		e.pos = max(e.pos-1, 0)
+	case tea.KeyRight, tea.KeyCtrlF:
+		e.pos = min(e.pos+1, len(e.value))
+	case tea.KeyCtrlLeft:
+		e.pos = e.wordStart()
+	case tea.KeyCtrlRight:
+		e.pos = e.wordEnd()
+	case tea.KeyHome, tea.KeyCtrlA:
+		e.pos = 0
+	case tea.KeyEnd, tea.KeyCtrlE:
+		e.pos = len(e.value)
+	}
+	return false
+}
+
+// insert inserts runes at the cursor and returns true if there were any
+func (e *lineEditor) insert(runes []rune) bool {
+	var clean []rune
+	for _, r := range runes {
+		switch {
+		case r == '\n' || r == '\r' || r == '\t':
+			clean = append(clean, ' ')
+		case unicode.IsControl(r):
+			// Dropped
+		default:
+			clean = append(clean, r)
+		}
+	}
+	e.splice(e.pos, e.pos, clean)
+	return len(clean) > 0
+}
+
+// splice replaces the runes from start to end with runes and leaves the
+// cursor after them. It always builds a new slice, since copies of the
+// model share the old one.
+func (e *lineEditor) splice(start, end int, runes []rune) {
+	value := make([]rune, 0, len(e.value)-(end-start)+len(runes))
+	value = append(value, e.value[:start]...)
+	value = append(value, runes...)
+	value = append(value, e.value[end:]...)
+	e.value = value
+	e.pos = start + len(runes)
+}
+
+// deleteWord deletes the word before the cursor along with any spaces
+// between it and the cursor
+func (e *lineEditor) deleteWord() bool {
+	start := e.wordStart()
+	if start == e.pos {
+		return false
+	}
+	e.splice(start, e.pos, nil)
+	return true
+}
+
+// wordStart returns the position of the start of the word before the cursor
+func (e lineEditor) wordStart() int {
+	i := e.pos
+	for i > 0 && unicode.IsSpace(e.value[i-1]) {
+		i--
+	}
+	for i > 0 && !unicode.IsSpace(e.value[i-1]) {
+		i--
+	}
+	return i
+}
+
+// wordEnd returns the position of the end of the word after the cursor
+func (e lineEditor) wordEnd() int {
+	i := e.pos
+	for i < len(e.value) && unicode.IsSpace(e.value[i]) {
+		i++
+	}
+	for i < len(e.value) && !unicode.IsSpace(e.value[i]) {
+		i++
+	}
+	return i
+}
+
+// View renders the text with a cursor, which is an underscore at the end
+// of the line and a highlighted character anywhere else
+func (e lineEditor) View() string {
+	if e.pos == len(e.value) {
+		return string(e.value) + "_"
+	}
+
+	var b strings.Builder
+	b.WriteString(string(e.value[:e.pos]))
+	b.WriteString(cursorStyle.Render(string(e.value[e.pos])))
+	b.WriteString(string(e.value[e.pos+1:]))
+	return b.String()
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestLineEditor(t *testing.T) {
+	// edit starts with value and the cursor at the end and applies keys
+	edit := func(value string, keys ...tea.KeyMsg) lineEditor {
+		var e lineEditor
+		e.SetValue(value)
+		for _, k := range keys {
+			e.update(k)
+		}
+		return e
+	}
+	key := func(k tea.KeyType) tea.KeyMsg { return tea.KeyMsg{Type: k} }
+	runes := func(s string) tea.KeyMsg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)} }
+
+	for _, tt := range []struct {
+		name  string
+		value string
+		keys  []tea.KeyMsg
+		want  string
+	}{
+		{"backspace removes an accented character", "café", []tea.KeyMsg{key(tea.KeyBackspace)}, "caf"},
+		{"backspace removes a CJK character", "東京", []tea.KeyMsg{key(tea.KeyBackspace)}, "東"},
+		{"backspace removes an emoji", "hi 🎤", []tea.KeyMsg{key(tea.KeyBackspace)}, "hi "},
+		{"backspace at the start does nothing", "abc", []tea.KeyMsg{key(tea.KeyHome), key(tea.KeyBackspace)}, "abc"},
+		{"typing multibyte runes", "", []tea.KeyMsg{runes("ñ"), runes("ü"), key(tea.KeySpace), runes("日本")}, "ñü 日本"},
+		{"left moves by rune", "naïve", []tea.KeyMsg{key(tea.KeyLeft), key(tea.KeyLeft), key(tea.KeyLeft), runes("X")}, "naXïve"},
+		{"right stops at the end", "ab", []tea.KeyMsg{key(tea.KeyRight), runes("c")}, "abc"},
+		{"home and end", "world", []tea.KeyMsg{key(tea.KeyHome), runes("hello "), key(tea.KeyEnd), runes("!")}, "hello world!"},
+		{"ctrl+a and ctrl+e", "b", []tea.KeyMsg{key(tea.KeyCtrlA), runes("a"), key(tea.KeyCtrlE), runes("c")}, "abc"},
+		{"delete removes the rune under the cursor", "héllo", []tea.KeyMsg{key(tea.KeyHome), key(tea.KeyRight), key(tea.KeyDelete)}, "hllo"},
+		{"ctrl+w deletes the last word", "über alles ", []tea.KeyMsg{key(tea.KeyCtrlW)}, "über "},
+		{"alt+backspace deletes the last word", "one two", []tea.KeyMsg{{Type: tea.KeyBackspace, Alt: true}}, "one "},
+		{"ctrl+w deletes the word before the cursor", "one two three", []tea.KeyMsg{key(tea.KeyCtrlLeft), key(tea.KeyCtrlW)}, "one three"},
+		{"ctrl+u deletes to the start", "one two three", []tea.KeyMsg{key(tea.KeyCtrlLeft), key(tea.KeyCtrlU)}, "three"},
+		{"ctrl+k deletes to the end", "one two three", []tea.KeyMsg{key(tea.KeyCtrlLeft), key(tea.KeyCtrlK)}, "one two "},
+		{"ctrl+right moves past a word", "one two", []tea.KeyMsg{key(tea.KeyHome), key(tea.KeyCtrlRight), runes("!")}, "one! two"},
+		{"paste inserts at the cursor", "one three", []tea.KeyMsg{key(tea.KeyCtrlLeft), {Type: tea.KeyRunes, Runes: []rune("two "), Paste: true}}, "one two three"},
+		{"paste turns line breaks into spaces", "", []tea.KeyMsg{{Type: tea.KeyRunes, Runes: []rune("Sous le\nciel\tde Paris"), Paste: true}}, "Sous le ciel de Paris"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := edit(tt.value, tt.keys...).Value(); got != tt.want {
+				t.Errorf("value = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("reports changes", func(t *testing.T) {
+		e := edit("ab")
+		if !e.update(runes("c")) {
+			t.Error("typing should change the value")
+		}
+		if e.update(key(tea.KeyLeft)) {
+			t.Error("moving the cursor should not change the value")
+		}
+	})
+
+	t.Run("edits don't affect copies", func(t *testing.T) {
+		e := edit("hello")
+		e.update(key(tea.KeyLeft))
+		cp := e
+		e.update(key(tea.KeyBackspace))
+		e.update(runes("X"))
+		if cp.Value() != "hello" {
+			t.Errorf("copy = %q, want %q", cp.Value(), "hello")
+		}
+	})
+
+	t.Run("view shows the cursor", func(t *testing.T) {
+		if got := edit("café").View(); got != "café_" {
+			t.Errorf("View() = %q, want %q", got, "café_")
+		}
+		e := edit("café", key(tea.KeyLeft))
+		if got := e.View(); !strings.HasPrefix(got, "caf") || !strings.Contains(got, "é") || strings.HasSuffix(got, "_") {
+			t.Errorf("View() = %q, want cursor on the last rune", got)
+		}
+	})
+}
+
+func TestTypingMultibyte(t *testing.T) {
+	m := initialModel(metadata{}, []string{"Non, je ne regrette rien", "Ça ira"})
+	m.state = stateTyping
+
+	for _, k := range []tea.KeyMsg{
+		{Type: tea.KeyRunes, Runes: []rune("Non je ne regrette riem")},
+		{Type: tea.KeyBackspace},
+		{Type: tea.KeyRunes, Runes: []rune("n")},
+		{Type: tea.KeyEnter},
+		{Type: tea.KeyRunes, Runes: []rune("Ça iré")},
+		{Type: tea.KeyBackspace},
+		{Type: tea.KeyRunes, Runes: []rune("a")},
+		{Type: tea.KeyEnter},
+	} {
+		newModel, _ := m.Update(k)
+		m = newModel.(model)
+	}
+
+	if m.userInputs[1] != "Ça ira" {
+		t.Errorf("input = %q, want %q", m.userInputs[1], "Ça ira")
+	}
+	if !m.results[0] || !m.results[1] {
+		t.Errorf("results = %v, want both lines right", m.results)
+	}
+}
@@ -0,0 +1,336 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// historyFilename is the name of the run history file inside the data dir
+const historyFilename = "history.jsonl"
+
+// trendLength is the number of recent attempts shown in a stats trend
+const trendLength = 5
+
+// historyRecord is a single completed run, stored as one JSON line
+type historyRecord struct {
+	File      string        `json:"file"`
+	Title     string        `json:"title,omitempty"`
+	Section   string        `json:"section"`
+	Mode      string        `json:"mode"`
+	Timestamp time.Time     `json:"timestamp"`
+	Elapsed   int64         `json:"elapsed_ms,omitempty"` // total time in timed mode
+	Lines     []historyLine `json:"lines"`
+}
+
+// historyLine is the outcome of a single typed line within a run
+type historyLine struct {
+	Section string  `json:"section"`
+	Text    string  `json:"text"`
+	Input   string  `json:"input"`
+	Correct bool    `json:"correct"`
+	Score   float64 `json:"score"`                // partial credit from 0 to 1
+	Hint    int     `json:"hint,omitempty"`       // highest hint level used
+	Latency int64   `json:"latency_ms,omitempty"` // first keystroke to Enter in timed mode
+}
+
+// historySavedMsg is sent after a run has been appended to the history file
+type historySavedMsg struct {
+	err error
+}
+
+// dataDir returns the directory recite stores its data in, following the
+// XDG base directory spec.
+func dataDir() (string, error) {
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return filepath.Join(dir, "recite"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "share", "recite"), nil
+}
+
+// defaultHistoryPath returns the path of the history file in the data dir
+func defaultHistoryPath() (string, error) {
+	dir, err := dataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, historyFilename), nil
+}
+
+// appendHistory appends rec to the history file at path, creating the file
+// and its parent directory if needed.
+func appendHistory(path string, rec historyRecord) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	buf, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(buf, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// loadHistory reads all records for file from the history file at path.
+// A missing history file is not an error.
+func loadHistory(path, file string) ([]historyRecord, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []historyRecord
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		var rec historyRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, lineNo, err)
+		}
+		if rec.File == file {
+			records = append(records, rec)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// historyRecord builds a record of the run that just completed
+func (m model) historyRecord(now time.Time) historyRecord {
+	rec := historyRecord{
+		File:      m.filename,
+		Title:     m.meta.Title,
+		Section:   m.sectionName(),
+		Mode:      m.mode.String(),
+		Timestamp: now,
+	}
+	if m.isTimed() {
+		rec.Elapsed = m.elapsed.Milliseconds()
+	}
+
+	section := ""
+	if len(m.lines) > 0 {
+		if s := m.sectionAt(m.lineIndex(0)); s >= 0 {
+			section = m.sections[s].name
+		}
+	}
+	for i, line := range m.lines {
+		if isComment(line) {
+			section = headerText(line)
+			continue
+		}
+		if section == "" {
+			section = "Intro"
+		}
+		rec.Lines = append(rec.Lines, historyLine{
+			Section: section,
+			Text:    lyricText(line),
+			Input:   m.userInputs[i],
+			Correct: m.results[i],
+			Score:   m.scores[i].value(),
+			Hint:    m.hintsUsed[i],
+			Latency: m.latencies[i].Milliseconds(),
+		})
+	}
+	return rec
+}
+
+// saveHistory returns a command that appends the completed run to the
+// history file. It returns nil if history is disabled.
+func (m model) saveHistory() tea.Cmd {
+	if m.historyPath == "" {
+		return nil
+	}
+	path, rec := m.historyPath, m.historyRecord(time.Now())
+	return func() tea.Msg {
+		return historySavedMsg{err: appendHistory(path, rec)}
+	}
+}
+
+// lineStats accumulates attempts for a single line or section
+type lineStats struct {
+	section  string
+	text     string
+	attempts int
+	correct  int
+	recent   []bool
+}
+
+func (s *lineStats) add(correct bool) {
+	s.attempts++
+	if correct {
+		s.correct++
+	}
+	s.recent = append(s.recent, correct)
+	if len(s.recent) > trendLength {
+		s.recent = s.recent[1:]
+	}
+}
+
+// trend renders recent attempts oldest first, e.g. "✗✓✓"
+func (s *lineStats) trend() string {
+	var b []rune
+	for _, ok := range s.recent {
+		if ok {
+			b = append(b, '✓')
+		} else {
+			b = append(b, '✗')
+		}
+	}
+	return string(b)
+}
+
+func percent(correct, total int) int {
+	if total == 0 {
+		return 0
+	}
+	return correct * 100 / total
+}
+
+// modeStats accumulates per section and per line accuracy for one mode
+type modeStats struct {
+	mode           string
+	sections       []*lineStats
+	lines          []*lineStats
+	sectionsByName map[string]*lineStats
+	linesByKey     map[[2]string]*lineStats
+}
+
+func newModeStats(mode string) *modeStats {
+	return &modeStats{
+		mode:           mode,
+		sectionsByName: make(map[string]*lineStats),
+		linesByKey:     make(map[[2]string]*lineStats),
+	}
+}
+
+func (ms *modeStats) add(line historyLine) {
+	sec := ms.sectionsByName[line.Section]
+	if sec == nil {
+		sec = &lineStats{section: line.Section}
+		ms.sectionsByName[line.Section] = sec
+		ms.sections = append(ms.sections, sec)
+	}
+	sec.add(line.Correct)
+
+	key := [2]string{line.Section, line.Text}
+	ls := ms.linesByKey[key]
+	if ls == nil {
+		ls = &lineStats{section: line.Section, text: line.Text}
+		ms.linesByKey[key] = ls
+		ms.lines = append(ms.lines, ls)
+	}
+	ls.add(line.Correct)
+}
+
+// writeStats writes a plain text report of records to w: every run in
+// chronological order, then accuracy per section and per line with the
+// most frequently missed lines first. Accuracy is reported separately for
+// each mode so scores from scaffolded modes aren't mixed with pure recall.
+func writeStats(w io.Writer, records []historyRecord) error {
+	if len(records) == 0 {
+		_, err := fmt.Fprintln(w, "No history yet.")
+		return err
+	}
+
+	var byMode []*modeStats
+	modesByName := make(map[string]*modeStats)
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "Runs:")
+	for _, rec := range records {
+		ms := modesByName[rec.Mode]
+		if ms == nil {
+			ms = newModeStats(rec.Mode)
+			modesByName[rec.Mode] = ms
+			byMode = append(byMode, ms)
+		}
+
+		correct := 0
+		for _, line := range rec.Lines {
+			if line.Correct {
+				correct++
+			}
+			ms.add(line)
+		}
+		fmt.Fprintf(tw, "  %s\t%s\t%s\t%d/%d\t%d%%\n",
+			rec.Timestamp.Local().Format("2006-01-02 15:04"), rec.Section, rec.Mode,
+			correct, len(rec.Lines), percent(correct, len(rec.Lines)))
+	}
+
+	for _, ms := range byMode {
+		fmt.Fprintln(tw)
+		fmt.Fprintf(tw, "By section (%s):\n", ms.mode)
+		for _, sec := range ms.sections {
+			fmt.Fprintf(tw, "  %s\t%d/%d\t%d%%\t%s\n",
+				sec.section, sec.correct, sec.attempts, percent(sec.correct, sec.attempts), sec.trend())
+		}
+
+		// Stable sort so equally missed lines stay in file order
+		lines := ms.lines
+		sort.SliceStable(lines, func(i, j int) bool {
+			return percent(lines[i].correct, lines[i].attempts) < percent(lines[j].correct, lines[j].attempts)
+		})
+
+		fmt.Fprintln(tw)
+		fmt.Fprintf(tw, "By line (%s, most missed first):\n", ms.mode)
+		for _, ls := range lines {
+			fmt.Fprintf(tw, "  %d%%\t%d/%d\t%s\t%s\t%s\n",
+				percent(ls.correct, ls.attempts), ls.correct, ls.attempts, ls.trend(), ls.section, ls.text)
+		}
+	}
+	return tw.Flush()
+}
+
+// runStats implements the "stats" subcommand
+func runStats(w io.Writer, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: recite stats <lyrics-file>")
+	}
+
+	file, err := filepath.Abs(args[0])
+	if err != nil {
+		return err
+	}
+	path, err := defaultHistoryPath()
+	if err != nil {
+		return err
+	}
+	records, err := loadHistory(path, file)
+	if err != nil {
+		return err
+	}
+
+	if len(records) > 0 && records[len(records)-1].Title != "" {
+		fmt.Fprintln(w, boldStyle.Render(records[len(records)-1].Title))
+		fmt.Fprintln(w)
+	}
+	return writeStats(w, records)
+}
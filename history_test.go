@@ -0,0 +1,208 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestDataDir(t *testing.T) {
+	t.Run("uses XDG_DATA_HOME when set", func(t *testing.T) {
+		t.Setenv("XDG_DATA_HOME", "/tmp/xdg")
+
+		dir, err := dataDir()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dir != filepath.Join("/tmp/xdg", "recite") {
+			t.Errorf("dataDir() = %q, want %q", dir, "/tmp/xdg/recite")
+		}
+	})
+
+	t.Run("falls back to ~/.local/share", func(t *testing.T) {
+		t.Setenv("XDG_DATA_HOME", "")
+		t.Setenv("HOME", "/home/test")
+
+		dir, err := dataDir()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := filepath.Join("/home/test", ".local", "share", "recite"); dir != want {
+			t.Errorf("dataDir() = %q, want %q", dir, want)
+		}
+	})
+}
+
+func TestHistory(t *testing.T) {
+	t.Run("appends and loads records for a file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "recite", historyFilename)
+
+		recs := []historyRecord{
+			{File: "/songs/a.txt", Section: "All sections", Mode: "Memory", Lines: []historyLine{{Text: "Line one", Correct: true}}},
+			{File: "/songs/b.txt", Section: "Chorus", Mode: "Practice"},
+			{File: "/songs/a.txt", Section: "Verse 1", Mode: "Practice", Lines: []historyLine{{Text: "Line one", Hint: 2}}},
+		}
+		for _, rec := range recs {
+			if err := appendHistory(path, rec); err != nil {
+				t.Fatalf("appendHistory error: %v", err)
+			}
+		}
+
+		got, err := loadHistory(path, "/songs/a.txt")
+		if err != nil {
+			t.Fatalf("loadHistory error: %v", err)
+		}
+		if len(got) != 2 {
+			t.Fatalf("len(records) = %d, want 2", len(got))
+		}
+		if got[1].Section != "Verse 1" {
+			t.Errorf("records[1].Section = %q, want %q", got[1].Section, "Verse 1")
+		}
+		if got[1].Lines[0].Hint != 2 {
+			t.Errorf("records[1].Lines[0].Hint = %d, want 2", got[1].Lines[0].Hint)
+		}
+	})
+
+	t.Run("missing history file is empty", func(t *testing.T) {
+		got, err := loadHistory(filepath.Join(t.TempDir(), historyFilename), "/songs/a.txt")
+		if err != nil {
+			t.Fatalf("loadHistory error: %v", err)
+		}
+		if len(got) != 0 {
+			t.Errorf("len(records) = %d, want 0", len(got))
+		}
+	})
+}
+
+func TestHistoryRecord(t *testing.T) {
+	t.Run("records lines with their sections", func(t *testing.T) {
+		m := initialModel(metadata{Title: "Song"}, []string{"Intro line", "# Chorus", "Chorus line"})
+		m.filename = "/songs/song.txt"
+		m.mode = modeMemory
+		m.results = []bool{false, true, true}
+		m.userInputs = []string{"intro", "", "chorus line"}
+		m.hintsUsed = []int{1, 0, 0}
+
+		now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+		rec := m.historyRecord(now)
+
+		if rec.File != "/songs/song.txt" || rec.Title != "Song" || rec.Mode != "Memory" || rec.Section != "All sections" {
+			t.Errorf("unexpected record header: %+v", rec)
+		}
+		if !rec.Timestamp.Equal(now) {
+			t.Errorf("Timestamp = %v, want %v", rec.Timestamp, now)
+		}
+		if len(rec.Lines) != 2 {
+			t.Fatalf("len(Lines) = %d, want 2 (header excluded)", len(rec.Lines))
+		}
+		if rec.Lines[0].Section != "Intro" || rec.Lines[0].Input != "intro" || rec.Lines[0].Correct || rec.Lines[0].Hint != 1 {
+			t.Errorf("Lines[0] = %+v", rec.Lines[0])
+		}
+		if rec.Lines[1].Section != "Chorus" || rec.Lines[1].Text != "Chorus line" || !rec.Lines[1].Correct {
+			t.Errorf("Lines[1] = %+v", rec.Lines[1])
+		}
+	})
+
+	t.Run("finishing a run saves history when enabled", func(t *testing.T) {
+		m := initialModel(metadata{}, []string{"Only line"})
+		m.historyPath = filepath.Join(t.TempDir(), historyFilename)
+		m.filename = "/songs/song.txt"
+		m.state = stateTyping
+		m.input.SetValue("Only line")
+
+		newModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		m = newModel.(model)
+		if cmd == nil {
+			t.Fatal("expected save command")
+		}
+
+		msg, ok := cmd().(historySavedMsg)
+		if !ok || msg.err != nil {
+			t.Fatalf("unexpected save result: %#v", msg)
+		}
+		recs, err := loadHistory(m.historyPath, "/songs/song.txt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(recs) != 1 || !recs[0].Lines[0].Correct {
+			t.Errorf("unexpected records: %+v", recs)
+		}
+	})
+
+	t.Run("finishing a run does nothing when history is disabled", func(t *testing.T) {
+		m := initialModel(metadata{}, []string{"Only line"})
+		m.state = stateTyping
+		m.input.SetValue("Only line")
+
+		_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		if cmd != nil {
+			t.Error("expected no command")
+		}
+	})
+}
+
+func TestWriteStats(t *testing.T) {
+	t.Run("reports runs, sections and most missed lines", func(t *testing.T) {
+		ts := time.Date(2026, 1, 2, 3, 4, 0, 0, time.Local)
+		records := []historyRecord{
+			{Section: "All sections", Mode: "Memory", Timestamp: ts, Lines: []historyLine{
+				{Section: "Verse 1", Text: "Easy line", Correct: true},
+				{Section: "Chorus", Text: "Hard line", Correct: false},
+			}},
+			{Section: "Chorus", Mode: "Memory", Timestamp: ts.Add(time.Hour), Lines: []historyLine{
+				{Section: "Chorus", Text: "Hard line", Correct: true},
+			}},
+		}
+
+		var buf bytes.Buffer
+		if err := writeStats(&buf, records); err != nil {
+			t.Fatal(err)
+		}
+		out := buf.String()
+
+		for _, want := range []string{"2026-01-02 03:04", "1/2", "50%", "Verse 1", "Chorus", "✗✓"} {
+			if !strings.Contains(out, want) {
+				t.Errorf("stats should contain %q, got:\n%s", want, out)
+			}
+		}
+		lineSection := out[strings.Index(out, "By line"):]
+		if strings.Index(lineSection, "Hard line") > strings.Index(lineSection, "Easy line") {
+			t.Errorf("most missed line should be listed first, got:\n%s", lineSection)
+		}
+	})
+
+	t.Run("reports each mode separately", func(t *testing.T) {
+		records := []historyRecord{
+			{Mode: "Memory", Lines: []historyLine{{Section: "Chorus", Text: "Line", Correct: false}}},
+			{Mode: "First letter", Lines: []historyLine{{Section: "Chorus", Text: "Line", Correct: true}}},
+		}
+
+		var buf bytes.Buffer
+		if err := writeStats(&buf, records); err != nil {
+			t.Fatal(err)
+		}
+		out := buf.String()
+
+		memory := out[strings.Index(out, "By section (Memory)"):strings.Index(out, "By section (First letter)")]
+		if !strings.Contains(memory, "0/1") || strings.Contains(memory, "1/1") {
+			t.Errorf("memory stats should not include first letter runs, got:\n%s", memory)
+		}
+		if !strings.Contains(out[strings.Index(out, "By section (First letter)"):], "1/1") {
+			t.Errorf("first letter stats missing, got:\n%s", out)
+		}
+	})
+
+	t.Run("reports empty history", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := writeStats(&buf, nil); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(buf.String(), "No history yet.") {
+			t.Errorf("unexpected output: %s", buf.String())
+		}
+	})
+}
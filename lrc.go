@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// singAlongLeadIn is how far before the first line a sing-along run starts
+// when practicing a single section
+const singAlongLeadIn = 3 * time.Second
+
+// singAlongTail is how long the last line stays up when there is no later
+// timestamp to end it
+const singAlongTail = 5 * time.Second
+
+// timestampRe matches a leading [mm:ss], [mm:ss.xx] or [mm:ss.xxx] timestamp
+var timestampRe = regexp.MustCompile(`^\s*\[(\d+):(\d{1,2})(?:[.:](\d{1,3}))?\]`)
+
+// lrcTagRe matches an LRC ID tag such as [ti:Title] or [offset:+500]
+var lrcTagRe = regexp.MustCompile(`^\s*\[([a-zA-Z]+):(.*)\]\s*$`)
+
+// splitTimestamps removes every leading timestamp from line and returns
+// them along with the rest of the line
+func splitTimestamps(line string) ([]time.Duration, string) {
+	var times []time.Duration
+	for {
+		match := timestampRe.FindStringSubmatch(line)
+		if match == nil {
+			return times, line
+		}
+
+		min, _ := strconv.Atoi(match[1])
+		sec, _ := strconv.Atoi(match[2])
+		d := time.Duration(min)*time.Minute + time.Duration(sec)*time.Second
+		if frac := match[3]; frac != "" {
+			// ".5" is half a second, ".50" and ".500" too
+			n, _ := strconv.Atoi(frac)
+			for i := len(frac); i < 3; i++ {
+				n *= 10
+			}
+			d += time.Duration(n) * time.Millisecond
+		}
+		times = append(times, d)
+		line = line[len(match[0]):]
+	}
+}
+
+// lineTime returns the timestamp at the start of a line, if it has one
+func lineTime(line string) (time.Duration, bool) {
+	if isComment(line) {
+		return 0, false
+	}
+	times, _ := splitTimestamps(line)
+	if len(times) == 0 {
+		return 0, false
+	}
+	return times[0], true
+}
+
+// stripTimestamps returns line without its leading timestamps
+func stripTimestamps(line string) string {
+	_, rest := splitTimestamps(line)
+	return rest
+}
+
+// formatTimestamp formats d as an LRC timestamp, e.g. [01:23.45]
+func formatTimestamp(d time.Duration) string {
+	cs := d.Milliseconds() / 10
+	return fmt.Sprintf("[%02d:%02d.%02d]", cs/6000, cs/100%60, cs%100)
+}
+
+// hasTimestamps returns true if any line has a timestamp
+func hasTimestamps(lines []string) bool {
+	for _, line := range lines {
+		if _, ok := lineTime(line); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// parseLRC converts the contents of an LRC file into metadata and lines in
+// the lyrics file format, with a single timestamp at the start of each line.
+// Lines with several timestamps are repeated at each time, and the lines are
+// sorted by time.
+func parseLRC(content []string) (metadata, []string, error) {
+	type timedLine struct {
+		t    time.Duration
+		text string
+	}
+
+	var meta metadata
+	var offset time.Duration
+	var timed []timedLine
+	for i, line := range content {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		times, text := splitTimestamps(line)
+		if len(times) == 0 {
+			if match := lrcTagRe.FindStringSubmatch(line); match != nil {
+				value := strings.TrimSpace(match[2])
+				switch strings.ToLower(match[1]) {
+				case "ti":
+					meta.Title = value
+				case "ar":
+					meta.Artist = value
+				case "al":
+					meta.Album = value
+				case "offset":
+					ms, err := strconv.Atoi(strings.TrimPrefix(value, "+"))
+					if err != nil {
+						return metadata{}, nil, fmt.Errorf("line %d: invalid offset %q", i+1, value)
+					}
+					offset = time.Duration(ms) * time.Millisecond
+				}
+			}
+			continue
+		}
+
+		text = strings.TrimSpace(text)
+		if text == "" {
+			continue // instrumental break
+		}
+		for _, t := range times {
+			// A positive offset shows lyrics sooner
+			timed = append(timed, timedLine{t: max(t-offset, 0), text: text})
+		}
+	}
+
+	sort.SliceStable(timed, func(i, j int) bool { return timed[i].t < timed[j].t })
+
+	lines := make([]string, len(timed))
+	for i, tl := range timed {
+		lines[i] = formatTimestamp(tl.t) + " " + tl.text
+	}
+	return meta, lines, nil
+}
+
+// songStart returns the position in the song the sing-along timeline starts
+// at: the beginning for the whole song, or just before the first line of the
+// selected sections
+func (m model) songStart() time.Duration {
+	if m.selected == nil {
+		return 0
+	}
+	for _, line := range m.lines {
+		if t, ok := lineTime(line); ok {
+			return max(t-singAlongLeadIn, 0)
+		}
+	}
+	return 0
+}
+
+// songPosition returns the current position in the song
+func (m model) songPosition() time.Duration {
+	return m.songStart() + m.now.Sub(m.runStart)
+}
+
+// lineDeadline returns the song position by which line i must be submitted,
+// which is when the next timed line starts
+func (m model) lineDeadline(i int) time.Duration {
+	var last time.Duration
+	for j, line := range m.lines {
+		t, ok := lineTime(line)
+		if !ok {
+			continue
+		}
+		if j > i {
+			return t
+		}
+		last = t
+	}
+	return last + singAlongTail
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestSplitTimestamps(t *testing.T) {
+	tests := []struct {
+		line  string
+		times []time.Duration
+		rest  string
+	}{
+		{"[00:12.34] Twinkle", []time.Duration{12340 * time.Millisecond}, " Twinkle"},
+		{"[1:02] Twinkle", []time.Duration{62 * time.Second}, " Twinkle"},
+		{"[00:01.5]x", []time.Duration{1500 * time.Millisecond}, "x"},
+		{"[00:01.005]x", []time.Duration{1005 * time.Millisecond}, "x"},
+		{"[00:10.00][01:20.00]Chorus", []time.Duration{10 * time.Second, 80 * time.Second}, "Chorus"},
+		{"[gonna|going to] go", nil, "[gonna|going to] go"},
+		{"No timestamp", nil, "No timestamp"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			times, rest := splitTimestamps(tt.line)
+			if len(times) != len(tt.times) {
+				t.Fatalf("times = %v, want %v", times, tt.times)
+			}
+			for i := range times {
+				if times[i] != tt.times[i] {
+					t.Errorf("times[%d] = %v, want %v", i, times[i], tt.times[i])
+				}
+			}
+			if rest != tt.rest {
+				t.Errorf("rest = %q, want %q", rest, tt.rest)
+			}
+		})
+	}
+}
+
+func TestFormatTimestamp(t *testing.T) {
+	if got := formatTimestamp(83450 * time.Millisecond); got != "[01:23.45]" {
+		t.Errorf("formatTimestamp = %q, want %q", got, "[01:23.45]")
+	}
+}
+
+func TestParseLRC(t *testing.T) {
+	t.Run("parses tags and sorts repeated lines", func(t *testing.T) {
+		meta, lines, err := parseLRC([]string{
+			"[ti:Twinkle Twinkle]",
+			"[ar:Jane Taylor]",
+			"[al:Rhymes for the Nursery]",
+			"[00:05.00][00:20.00]Twinkle twinkle little star",
+			"[00:10.00]How I wonder what you are",
+			"[00:15.00]",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if meta.Title != "Twinkle Twinkle" || meta.Artist != "Jane Taylor" || meta.Album != "Rhymes for the Nursery" {
+			t.Errorf("meta = %+v", meta)
+		}
+		want := []string{
+			"[00:05.00] Twinkle twinkle little star",
+			"[00:10.00] How I wonder what you are",
+			"[00:20.00] Twinkle twinkle little star",
+		}
+		if strings.Join(lines, "|") != strings.Join(want, "|") {
+			t.Errorf("lines = %q, want %q", lines, want)
+		}
+	})
+
+	t.Run("applies offset", func(t *testing.T) {
+		_, lines, err := parseLRC([]string{"[offset:+500]", "[00:05.00]Line"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if lines[0] != "[00:04.50] Line" {
+			t.Errorf("lines[0] = %q, want %q", lines[0], "[00:04.50] Line")
+		}
+	})
+
+	t.Run("rejects invalid offset", func(t *testing.T) {
+		if _, _, err := parseLRC([]string{"[offset:soon]"}); err == nil {
+			t.Error("expected error")
+		}
+	})
+
+	t.Run("readFile reads .lrc files", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "song.lrc")
+		if err := os.WriteFile(path, []byte("[ti:Song]\n[00:01.00]Line one\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		meta, lines, err := readFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if meta.Title != "Song" || len(lines) != 1 || lyricText(lines[0]) != "Line one" {
+			t.Errorf("meta = %+v, lines = %q", meta, lines)
+		}
+	})
+}
+
+func TestTimestampedLines(t *testing.T) {
+	t.Run("timestamps are not part of the lyric", func(t *testing.T) {
+		line := "[00:12.00] Twinkle twinkle little star"
+		if !linesMatch("twinkle twinkle little star", line) {
+			t.Error("linesMatch should ignore the timestamp")
+		}
+		if got := lyricText(line); got != "Twinkle twinkle little star" {
+			t.Errorf("lyricText = %q", got)
+		}
+	})
+
+	t.Run("sing-along is only offered with timestamps", func(t *testing.T) {
+		m := initialModel(metadata{}, []string{"Line one"})
+		if strings.Contains(m.View(), "Sing-along") {
+			t.Error("sing-along should not be offered without timestamps")
+		}
+
+		m = initialModel(metadata{}, []string{"[00:01.00] Line one"})
+		available := m.availableModes()
+		if available[len(available)-1] != modeSingAlong {
+			t.Fatalf("sing-along should be offered last, got %v", available)
+		}
+		if !strings.Contains(m.View(), "Sing-along - ") {
+			t.Error("sing-along should be offered with timestamps")
+		}
+		for range available {
+			newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyDown})
+			m = newModel.(model)
+		}
+		newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		if newModel.(model).mode != modeSingAlong {
+			t.Error("selecting the last mode should select sing-along")
+		}
+	})
+}
+
+func TestSingAlong(t *testing.T) {
+	lines := []string{
+		"# Verse",
+		"[00:05.00] Line one",
+		"[00:10.00] Line two",
+		"# Chorus",
+		"[00:15.00] Line three",
+	}
+
+	t.Run("line deadline is the next timestamp", func(t *testing.T) {
+		m := initialModel(metadata{}, lines)
+		if got := m.lineDeadline(1); got != 10*time.Second {
+			t.Errorf("lineDeadline(1) = %v, want 10s", got)
+		}
+		if got := m.lineDeadline(2); got != 15*time.Second {
+			t.Errorf("lineDeadline(2) = %v, want 15s (across a header)", got)
+		}
+		if got := m.lineDeadline(4); got != 15*time.Second+singAlongTail {
+			t.Errorf("lineDeadline(4) = %v, want 15s plus tail", got)
+		}
+	})
+
+	t.Run("section starts just before its first line", func(t *testing.T) {
+		m := initialModel(metadata{}, lines)
+		m.selectSection(1)
+		if got := m.songStart(); got != 12*time.Second {
+			t.Errorf("songStart = %v, want 12s", got)
+		}
+	})
+
+	t.Run("lines the song passes are missed", func(t *testing.T) {
+		clock, advance := fakeClock()
+		m := initialModel(metadata{}, lines)
+		m.clock = clock
+		m.mode = modeSingAlong
+		m.selectSection(-1)
+		m.state = stateTyping
+		m.skipComments()
+		m.startRun()
+
+		// Type line one correctly without pressing Enter in time
+		m.input.SetValue("Line one")
+		advance(11 * time.Second)
+		newModel, cmd := m.Update(tickMsg{id: m.tickID, t: clock()})
+		m = newModel.(model)
+
+		if m.currentLine != 2 {
+			t.Fatalf("currentLine = %d, want 2", m.currentLine)
+		}
+		if m.results[1] || !m.timedOut[1] {
+			t.Error("line one should be missed")
+		}
+		if cmd == nil {
+			t.Error("expected another tick")
+		}
+
+		// Line two is typed in time
+		m.input.SetValue("Line two")
+		newModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		m = newModel.(model)
+		if !m.results[2] {
+			t.Error("line two should be correct")
+		}
+
+		// The song ends before line three is submitted
+		advance(10 * time.Second)
+		newModel, _ = m.Update(tickMsg{id: m.tickID, t: clock()})
+		m = newModel.(model)
+		if m.state != stateResult {
+			t.Fatalf("state = %v, want stateResult", m.state)
+		}
+		if !strings.Contains(m.View(), "(missed)") {
+			t.Error("result should show missed lines")
+		}
+	})
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+)
+
+// maxVariants caps how many accepted forms a single line expands to so a
+// line with many alternative groups can't blow up
+const maxVariants = 64
+
+// lyric is an expected line along with every form accepted for it.
+//
+// Alternatives are declared inline as "[gonna|going to]", where an empty
+// option makes the words optional ("[oh |]"), or as whole-line alternatives
+// after "||". Brackets without a "|" are kept as literal text. A leading
+// timestamp such as "[01:23.45]" is not part of the lyric.
+type lyric struct {
+	text     string   // canonical form shown to the user
+	variants []string // every accepted form, canonical first
+}
+
+// parseLyric parses the alternatives in a line of the lyrics file
+func parseLyric(line string) lyric {
+	line = stripTimestamps(line)
+
+	var l lyric
+	for _, part := range strings.Split(line, "||") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		for _, v := range expandAlternatives(part) {
+			if len(l.variants) < maxVariants {
+				l.variants = append(l.variants, v)
+			}
+		}
+	}
+
+	if len(l.variants) == 0 {
+		l.variants = []string{strings.TrimSpace(line)}
+	}
+	l.text = l.variants[0]
+	return l
+}
+
+// lyricText returns the canonical form of a line, without any alternatives
+func lyricText(line string) string {
+	if isComment(line) {
+		return line
+	}
+	return parseLyric(line).text
+}
+
+// firstLetters returns the first letter of each word of a line, keeping
+// punctuation, e.g. "Twinkle, twinkle little star" becomes "T, t l s"
+func firstLetters(line string) string {
+	words := strings.Fields(lyricText(line))
+	for i, w := range words {
+		var b strings.Builder
+		seen := false
+		for _, r := range w {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				if seen {
+					continue
+				}
+				seen = true
+			}
+			b.WriteRune(r)
+		}
+		words[i] = b.String()
+	}
+	return strings.Join(words, " ")
+}
+
+// expandAlternatives returns every combination of the "[a|b]" groups in s,
+// with whitespace collapsed.
+func expandAlternatives(s string) []string {
+	results := []string{""}
+	for s != "" {
+		open := strings.IndexByte(s, '[')
+		end := -1
+		if open >= 0 {
+			end = strings.IndexByte(s[open:], ']')
+		}
+		if open < 0 || end < 0 {
+			results = appendEach(results, []string{s})
+			break
+		}
+		end += open
+
+		inner := s[open+1 : end]
+		if !strings.Contains(inner, "|") {
+			// Not a group of alternatives, e.g. "[Repeat]"
+			results = appendEach(results, []string{s[:end+1]})
+		} else {
+			results = appendEach(results, []string{s[:open]})
+			results = appendEach(results, strings.Split(inner, "|"))
+		}
+		s = s[end+1:]
+	}
+
+	for i, r := range results {
+		results[i] = strings.Join(strings.Fields(r), " ")
+	}
+	return results
+}
+
+// appendEach returns every prefix followed by every suffix, capped at
+// maxVariants
+func appendEach(prefixes, suffixes []string) []string {
+	out := make([]string, 0, len(prefixes)*len(suffixes))
+	for _, p := range prefixes {
+		for _, s := range suffixes {
+			if len(out) == maxVariants {
+				return out
+			}
+			out = append(out, p+s)
+		}
+	}
+	return out
+}
+
+// closestVariant returns the accepted form of l that input is closest to,
+// by number of mismatched words. Ties go to the earlier form.
+func (rs *ruleSet) closestVariant(input string, l lyric) string {
+	if len(l.variants) == 1 {
+		return l.variants[0]
+	}
+
+	best, bestCost := l.variants[0], -1
+	inputWords := rs.words(input)
+	for _, v := range l.variants {
+		cost := 0
+		for _, op := range rs.alignWords(inputWords, rs.words(v)) {
+			if op.kind != opMatch {
+				cost++
+			}
+		}
+		if bestCost < 0 || cost < bestCost {
+			best, bestCost = v, cost
+		}
+	}
+	return best
+}
+
+// prefixVariant returns the accepted form of l whose opening words best
+// match what has been typed so far, so hints follow the alternative the
+// user has started on. A partially typed last word matches by prefix.
+func (rs *ruleSet) prefixVariant(input string, l lyric) string {
+	if len(l.variants) == 1 {
+		return l.variants[0]
+	}
+
+	inputWords := rs.words(input)
+	partial := rs.partialWord(input)
+
+	best, bestCount := l.variants[0], -1
+	for _, v := range l.variants {
+		words := rs.words(v)
+		count := 0
+		for i, w := range inputWords {
+			if i >= len(words) {
+				break
+			}
+			if partial && i == len(inputWords)-1 {
+				if strings.HasPrefix(rs.normalizeWord(words[i]), rs.normalizeWord(w)) {
+					count++
+				}
+				break
+			}
+			if !rs.wordsMatch(w, words[i]) {
+				break
+			}
+			count++
+		}
+		if count > bestCount {
+			best, bestCount = v, count
+		}
+	}
+	return best
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestParseLyric(t *testing.T) {
+	tests := []struct {
+		line     string
+		text     string
+		variants []string
+	}{
+		{"Plain line", "Plain line", []string{"Plain line"}},
+		{"I'm [gonna|going to] be", "I'm gonna be", []string{"I'm gonna be", "I'm going to be"}},
+		{"[Oh |]baby baby", "Oh baby baby", []string{"Oh baby baby", "baby baby"}},
+		{"[a|b] and [c|d]", "a and c", []string{"a and c", "a and d", "b and c", "b and d"}},
+		{"Studio line || Live line", "Studio line", []string{"Studio line", "Live line"}},
+		{"[Repeat] the verse", "[Repeat] the verse", []string{"[Repeat] the verse"}},
+		{"Unclosed [bracket", "Unclosed [bracket", []string{"Unclosed [bracket"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			l := parseLyric(tt.line)
+			if l.text != tt.text {
+				t.Errorf("text = %q, want %q", l.text, tt.text)
+			}
+			if strings.Join(l.variants, "|") != strings.Join(tt.variants, "|") {
+				t.Errorf("variants = %q, want %q", l.variants, tt.variants)
+			}
+		})
+	}
+
+	t.Run("caps the number of variants", func(t *testing.T) {
+		l := parseLyric(strings.Repeat("[a|b|c] ", 10))
+		if len(l.variants) != maxVariants {
+			t.Errorf("len(variants) = %d, want %d", len(l.variants), maxVariants)
+		}
+	})
+}
+
+func TestAlternatives(t *testing.T) {
+	const line = "Oh I [wanna|want to] hold your hand || I wanna hold your hand tonight"
+
+	t.Run("linesMatch accepts any alternative", func(t *testing.T) {
+		for _, input := range []string{
+			"oh I wanna hold your hand",
+			"oh I want to hold your hand",
+			"I wanna hold your hand tonight",
+		} {
+			if !linesMatch(input, line) {
+				t.Errorf("linesMatch(%q) = false, want true", input)
+			}
+		}
+		if linesMatch("I hold your hand", line) {
+			t.Error("linesMatch should reject input matching no alternative")
+		}
+	})
+
+	t.Run("formatDiff uses the closest alternative", func(t *testing.T) {
+		got := formatDiff("I wanna hold your hand tonite", line)
+		if !strings.Contains(got, "(tonight)") {
+			t.Errorf("diff should compare to the live line, got %q", got)
+		}
+		if strings.Contains(got, "[Oh]") {
+			t.Errorf("diff should not compare to the studio line, got %q", got)
+		}
+	})
+
+	t.Run("getNextWordHint follows the started alternative", func(t *testing.T) {
+		l := "I'm [gonna|going to] be there"
+		if got := getNextWordHint("I'm ", l); got != "gonna" {
+			t.Errorf("hint = %q, want %q", got, "gonna")
+		}
+		if got := getNextWordHint("I'm going ", l); got != "to" {
+			t.Errorf("hint = %q, want %q", got, "to")
+		}
+	})
+
+	t.Run("scoreLine scores against the best alternative", func(t *testing.T) {
+		if got := scoreLine("I wanna hold your hand tonight", line, 0).value(); got != 1 {
+			t.Errorf("value() = %v, want 1", got)
+		}
+	})
+
+	t.Run("view shows the canonical line", func(t *testing.T) {
+		m := initialModel(metadata{}, []string{line})
+		m.state = stateTyping
+		m.mode = modePractice
+		view := m.View()
+
+		if !strings.Contains(view, "Oh I wanna hold your hand") {
+			t.Errorf("view should show canonical line, got: %s", view)
+		}
+		if strings.Contains(view, "||") || strings.Contains(view, "|want") {
+			t.Errorf("view should not show alternative syntax, got: %s", view)
+		}
+	})
+}
+
+func TestFirstLetters(t *testing.T) {
+	for _, tt := range []struct {
+		line string
+		want string
+	}{
+		{"Twinkle, twinkle little star", "T, t l s"},
+		{"How I wonder what you are!", "H I w w y a!"},
+		{"I'm [gonna|going to] be there", "I' g b t"},
+		{"[00:12.00] Don't stop me now", "D' s m n"},
+		{"99 red balloons", "9 r b"},
+	} {
+		if got := firstLetters(tt.line); got != tt.want {
+			t.Errorf("firstLetters(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+
+	t.Run("view shows first letters", func(t *testing.T) {
+		m := initialModel(metadata{}, []string{"Twinkle, twinkle little star"})
+		m.mode = modeFirstLetter
+		m.state = stateTyping
+
+		view := m.View()
+		if !strings.Contains(view, "T, t l s") {
+			t.Errorf("view should show first letters, got: %s", view)
+		}
+		if strings.Contains(view, "twinkle") {
+			t.Errorf("view should not show the full line, got: %s", view)
+		}
+	})
+
+	t.Run("typing the full line is correct", func(t *testing.T) {
+		m := initialModel(metadata{}, []string{"Twinkle, twinkle little star"})
+		m.mode = modeFirstLetter
+		m.state = stateTyping
+		m.input.SetValue("twinkle twinkle little star")
+
+		newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		if m = newModel.(model); !m.results[0] {
+			t.Error("typing the full line should be correct")
+		}
+	})
+}
@@ -3,187 +3,366 @@ package main
 import (
 	"bufio"
 	"fmt"
+	"math/rand"
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 	"unicode"
 
+	"github.com/charmbracelet/bubbles/textarea"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"golang.org/x/text/unicode/norm"
 	"gopkg.in/yaml.v3"
 )
 
 var (
-	boldStyle    = lipgloss.NewStyle().Bold(true)
-	greenStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
-	redStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
-	dimStyle     = lipgloss.NewStyle().Faint(true)
+	boldStyle   = lipgloss.NewStyle().Bold(true)
+	greenStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
+	redStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+	dimStyle    = lipgloss.NewStyle().Faint(true)
 	headerStyle = lipgloss.NewStyle().Bold(true).Underline(true)
+	strikeStyle = redStyle.Strikethrough(true)
 )
 
 type state int
 
 const (
-	stateSectionSelect state = iota
+	stateModeSelect state = iota
+	stateSectionSelect
 	stateTyping
+	stateQuiz
+	stateReorder
+	stateRecall
 	stateResult
 )
 
+// mode determines how lines are presented while the user types them
+type mode int
+
+const (
+	modePractice     mode = iota // target line is shown above the input
+	modeMemory                   // target line is hidden
+	modeSingAlong                // lines must be typed in time with their timestamps
+	modeCloze                    // some words are blanked and only those are typed
+	modeFirstLetter              // only the first letter of each word is shown
+	modeQuiz                     // pick the next line from several choices
+	modeReorder                  // put the shuffled lines of a section back in order
+	modeChain                    // only the previous line is shown as a cue
+	modeReverseChain             // only the next line is shown, going backwards
+	modeSection                  // a whole section is typed at once
+)
+
+// modes lists the selectable modes in the order shown on the mode select screen
+var modes = []mode{modePractice, modeMemory, modeCloze, modeFirstLetter, modeQuiz, modeReorder, modeChain, modeReverseChain, modeSection, modeSingAlong}
+
+func (md mode) String() string {
+	switch md {
+	case modePractice:
+		return "Practice"
+	case modeMemory:
+		return "Memory"
+	case modeSingAlong:
+		return "Sing-along"
+	case modeCloze:
+		return "Cloze"
+	case modeFirstLetter:
+		return "First letter"
+	case modeQuiz:
+		return "Quiz"
+	case modeReorder:
+		return "Reorder"
+	case modeChain:
+		return "Chain"
+	case modeReverseChain:
+		return "Reverse chain"
+	case modeSection:
+		return "Section recall"
+	default:
+		return fmt.Sprintf("mode(%d)", int(md))
+	}
+}
+
+// description returns a short explanation shown next to the mode name
+func (md mode) description() string {
+	switch md {
+	case modePractice:
+		return "the line is displayed and you type it back"
+	case modeMemory:
+		return "type each line from memory without seeing it"
+	case modeSingAlong:
+		return "type each line before the next one is sung, in time with the track"
+	case modeCloze:
+		return "fill in the blanks, with more words blanked each time you pass"
+	case modeFirstLetter:
+		return "type each line from the first letter of every word"
+	case modeQuiz:
+		return "pick the line that comes next to learn the order"
+	case modeReorder:
+		return "put the shuffled lines back in order"
+	case modeChain:
+		return "type each line given only the line before it"
+	case modeReverseChain:
+		return "type each line given only the line after it, working backwards"
+	case modeSection:
+		return "type a whole section in one go, then compare it line by line"
+	default:
+		return ""
+	}
+}
+
+// picksLines returns true for the modes where lines are picked or arranged
+// rather than typed
+func (md mode) picksLines() bool {
+	return md == modeQuiz || md == modeReorder
+}
+
+// availableModes returns the modes that can be used with the file. Sing-along
+// needs timestamps, and isn't offered in a review, where the timelines of
+// different songs would run into each other.
+func (m model) availableModes() []mode {
+	var available []mode
+	for _, md := range modes {
+		if md == modeSingAlong && (!hasTimestamps(m.allLines) || m.review != nil) {
+			continue
+		}
+		available = append(available, md)
+	}
+	return available
+}
+
 // metadata holds song information from YAML front matter
 type metadata struct {
-	Title  string `yaml:"title"`
-	Artist string `yaml:"artist"`
+	Title    string      `yaml:"title"`
+	Artist   string      `yaml:"artist"`
+	Album    string      `yaml:"album"`
+	Year     year        `yaml:"year"` // e.g. 1971 or "1970s"
+	Tags     tagList     `yaml:"tags"`
+	Language string      `yaml:"language"` // language tag such as "en" or "ja"
+	Key      string      `yaml:"key"`      // musical key, e.g. "G" or "F# minor"
+	Source   string      `yaml:"source"`   // where the lyrics came from, e.g. a URL
+	Notes    text        `yaml:"notes"`
+	Match    matchConfig `yaml:"match"`
+
+	// Recite sets how the file is practiced
+	Recite practiceConfig `yaml:"recite"`
+
+	// Extra holds any other keys, so they're kept rather than dropped
+	Extra map[string]any `yaml:",inline"`
+
+	rules *ruleSet // built from Match and Language by readFile
 }
 
 type section struct {
 	name     string
 	startIdx int // inclusive
-	endIdx   int // exclusive
+	endIdx   int // exclusive, at the next header even if it's nested
+	parent   int // index of the enclosing section, -1 at the top level
+	depth    int // number of enclosing sections
 }
 
 type model struct {
-	meta            metadata  // song metadata from front matter
-	allLines        []string  // all lines from the file
-	lines           []string  // lines to practice (filtered by section)
-	lineIndices     []int     // maps filtered line indices to allLines indices
-	sections        []section // parsed sections
-	selectedSection int       // -1 for all sections
-	currentLine     int
-	input           string
-	results         []bool
-	userInputs      []string // stores user's input for each line (for diff display)
-	state           state
-	hint            string // current hint to display (next word or full line)
-	hintLevel       int    // 0 = no hint, 1 = word hint, 2 = full line hint
+	filename      string         // absolute path of the lyrics file
+	historyPath   string         // history file runs are saved to, empty to disable
+	historyErr    error          // error from saving the last run, if any
+	review        *reviewSession // cards being reviewed, nil outside of a review
+	reviewErr     error          // error from saving the review schedule, if any
+	meta          metadata       // song metadata from front matter
+	rules         *ruleSet       // matching rules from front matter
+	allLines      []string       // all lines from the file
+	lines         []string       // lines to practice (filtered by section)
+	lineIndices   []int          // maps filtered line indices to allLines indices
+	sections      []section      // parsed sections
+	firstOf       []int          // first section with the same lines as each section
+	selected      []int          // sections being practiced, nil for all
+	sectionList   sectionList    // state of the section select screen
+	mode          mode           // how lines are presented
+	modeCursor    int            // mode highlighted on the mode select screen
+	clozeLevel    int            // index into clozeRatios in cloze mode
+	currentLine   int
+	input         lineEditor
+	results       []bool
+	userInputs    []string    // stores user's input for each line (for diff display)
+	hintsUsed     []int       // highest hint level used on each line
+	scores        []lineScore // partial credit for each line
+	state         state
+	hint          string         // current hint to display (next word or full line)
+	hintLevel     int            // 0 = no hint, 1 = word hint, 2 = full line hint
+	attempts      []int          // runs each line of allLines has been in since the last full run
+	retries       int            // runs of just the missed lines since the last full run
+	quiz          []quizQuestion // question for each line in quiz mode
+	recall        textarea.Model // multi-line input in section recall mode
+	order         []int          // arrangement of lines in reorder mode, as indices into lines
+	orderCursor   int            // position in order the cursor is on
+	orderHeld     bool           // the line under the cursor moves with it
+	rand          *rand.Rand     // shuffles quiz choices and lines to reorder
+	shuffle       bool           // practice the sections in random order
+	once          bool           // sing repeated sections once, then just their first line
+	hints         hintPolicy     // which hints Tab gives
+	hideHeaders   bool           // leave section headers out while typing
+	sectionPreset bool           // section given on the command line
+
+	// Timed mode
+	timed     bool             // show a running clock and record timing
+	timeLimit time.Duration    // per-line limit that auto-submits, 0 for none
+	clock     func() time.Time // returns the current time
+	tickID    int              // identifies ticks from the current run
+	now       time.Time        // time of the last tick
+	runStart  time.Time        // time the run began
+	lineShown time.Time        // time the current line was shown
+	lineStart time.Time        // time of the first keystroke on the current line
+	elapsed   time.Duration    // time from the start of the run to the last submit
+	latencies []time.Duration  // time from first keystroke to Enter for each line
+	timedOut  []bool           // lines submitted because their time ran out
 }
 
 func isComment(line string) bool {
 	return strings.HasPrefix(strings.TrimSpace(line), "#")
 }
 
-// headerText strips the leading '#' and whitespace from a comment line
+// headerText strips the leading '#'s and whitespace from a comment line
 func headerText(line string) string {
 	line = strings.TrimSpace(line)
-	line = strings.TrimPrefix(line, "#")
+	line = strings.TrimLeft(line, "#")
 	return strings.TrimSpace(line)
 }
 
+// headerLevel returns the number of '#'s a header starts with, so "# Act 1"
+// is level 1 and "## Scene 1" is level 2
+func headerLevel(line string) int {
+	line = strings.TrimSpace(line)
+	return len(line) - len(strings.TrimLeft(line, "#"))
+}
+
 // normalize removes all punctuation and spaces, and lowercases the string
 // for forgiving comparison of user input to expected lyrics
 func normalize(s string) string {
-	var b strings.Builder
-	for _, r := range s {
-		if unicode.IsLetter(r) || unicode.IsDigit(r) {
-			b.WriteRune(unicode.ToLower(r))
-		}
-	}
-	return b.String()
+	return normalizeWord(s)
 }
 
-// normalizeWord normalizes a single word, removing punctuation and lowercasing
+// normalizeWord normalizes a single word, removing punctuation and
+// lowercasing. Text is first put in NFKC form so precomposed and combining
+// accents, and full-width and half-width forms, compare equal. Combining
+// marks left after that are kept, since in many scripts they are vowels.
 func normalizeWord(s string) string {
 	var b strings.Builder
-	for _, r := range s {
-		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+	for _, r := range norm.NFKC.String(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.M, r) {
 			b.WriteRune(unicode.ToLower(r))
 		}
 	}
 	return b.String()
 }
 
-// wordsMatch compares two words with tolerance for g-dropping
-// (e.g., "stayin" matches "staying", "nothin" matches "nothing")
-// Only applies to words where the "-in" form is at least 5 characters
-// to avoid matching unrelated words like "sin" and "sing"
+// wordsMatch compares two words using the default rules, which tolerate
+// g-dropping (e.g., "stayin" matches "staying") along with common
+// contractions, number words and British/American spellings
 func wordsMatch(a, b string) bool {
-	a = normalizeWord(a)
-	b = normalizeWord(b)
+	return defaultRules.wordsMatch(a, b)
+}
 
-	if a == b {
-		return true
-	}
+// linesMatch compares two lines using the default rules
+func linesMatch(input, expected string) bool {
+	return defaultRules.linesMatch(input, expected)
+}
 
-	// Check for g-dropping: "in" ending vs "ing" ending
-	// Require the "-in" form to be at least 5 chars (e.g., "stayin" not "sin")
-	if strings.HasSuffix(a, "in") && strings.HasSuffix(b, "ing") && len(a) >= 5 {
-		return a == b[:len(b)-1] // compare "stayin" with "stayin" (from "staying")
-	}
-	if strings.HasSuffix(b, "in") && strings.HasSuffix(a, "ing") && len(b) >= 5 {
-		return b == a[:len(a)-1]
-	}
+// formatDiff formats a diff using the default rules
+func formatDiff(input, expected string) string {
+	return defaultRules.formatDiff(input, expected)
+}
 
+// linesMatch compares two lines word by word with forgiving comparison.
+// Input matches if it matches any of the line's accepted alternatives.
+func (rs *ruleSet) linesMatch(input, expected string) bool {
+	for _, v := range parseLyric(expected).variants {
+		if rs.variantMatches(input, v) {
+			return true
+		}
+	}
 	return false
 }
 
-// linesMatch compares two lines word by word with forgiving comparison
-func linesMatch(input, expected string) bool {
-	inputWords := strings.Fields(input)
-	expectedWords := strings.Fields(expected)
-
-	if len(inputWords) != len(expectedWords) {
-		return false
+// variantMatches compares input to a single accepted form of a line
+func (rs *ruleSet) variantMatches(input, variant string) bool {
+	inputWords, words := rs.words(input), rs.words(variant)
+	if rs.reordered(inputWords, words) {
+		return true
 	}
-
-	for i := range inputWords {
-		if !wordsMatch(inputWords[i], expectedWords[i]) {
+	for _, op := range rs.alignWords(inputWords, words) {
+		if op.kind != opMatch {
 			return false
 		}
 	}
-
 	return true
 }
 
 // formatDiff returns a word-by-word diff between user input and expected line.
-// Green words match, red words differ, with expected shown in parentheses.
-func formatDiff(input, expected string) string {
-	inputWords := strings.Fields(input)
-	expectedWords := strings.Fields(expected)
-
-	var b strings.Builder
-	maxLen := len(inputWords)
-	if len(expectedWords) > maxLen {
-		maxLen = len(expectedWords)
+// Words are aligned so a missing or extra word doesn't affect the words after
+// it. Green words match, red words differ, with expected shown in parentheses.
+// If the line has alternatives, the diff is against the closest one. In
+// strict mode, words that are only wrong in case or punctuation have the
+// characters that differ highlighted.
+func (rs *ruleSet) formatDiff(input, expected string) string {
+	expected = rs.closestVariant(input, parseLyric(expected))
+	inputWords, words := rs.words(input), rs.words(expected)
+	if rs.reordered(inputWords, words) {
+		// Every word is right, just not in order
+		return greenStyle.Render(rs.joinWords(inputWords))
 	}
 
-	for i := 0; i < maxLen; i++ {
-		if i > 0 {
-			b.WriteString(" ")
-		}
-
-		var inputWord, expectedWord string
-		if i < len(inputWords) {
-			inputWord = inputWords[i]
+	var b strings.Builder
+	var prev string
+	for _, op := range rs.alignWords(inputWords, words) {
+		word := op.input
+		if word == "" {
+			word = op.expected
 		}
-		if i < len(expectedWords) {
-			expectedWord = expectedWords[i]
+		if prev != "" && rs.spaceBetween(prev, word) {
+			b.WriteString(" ")
 		}
+		prev = word
 
-		if inputWord == "" {
-			// Missing word - show expected in red
-			b.WriteString(redStyle.Render("[" + expectedWord + "]"))
-		} else if expectedWord == "" {
-			// Extra word - show in red with strikethrough
-			b.WriteString(redStyle.Render(inputWord))
-		} else if wordsMatch(inputWord, expectedWord) {
+		switch op.kind {
+		case opMatch:
 			// Correct word - show in green
-			b.WriteString(greenStyle.Render(inputWord))
-		} else {
+			b.WriteString(greenStyle.Render(op.input))
+		case opSubstitute:
+			if rs.strictness == strictnessStrict && rs.normalizeWord(op.input) == rs.normalizeWord(op.expected) {
+				writeCharDiff(&b, op.input, op.expected)
+				continue
+			}
 			// Wrong word - show user input in red, expected in parentheses
-			b.WriteString(redStyle.Render(inputWord))
-			b.WriteString(dimStyle.Render("(" + expectedWord + ")"))
+			b.WriteString(redStyle.Render(op.input))
+			b.WriteString(dimStyle.Render("(" + op.expected + ")"))
+		case opDelete:
+			// Missing word - show expected in red
+			b.WriteString(redStyle.Render("[" + op.expected + "]"))
+		case opInsert:
+			// Extra word - show in red with strikethrough
+			b.WriteString(strikeStyle.Render(op.input))
 		}
 	}
 
 	return b.String()
 }
 
-// getNextWordHint returns a hint for the next word the user should type.
-// It looks at what the user has typed so far and returns the next word from the expected line.
+// getNextWordHint returns a hint for the next word using the default rules
 func getNextWordHint(input, expected string) string {
-	expectedWords := strings.Fields(expected)
-	inputWords := strings.Fields(input)
+	return defaultRules.nextWordHint(input, expected)
+}
+
+// nextWordHint returns a hint for the next word the user should type.
+// It looks at what the user has typed so far and returns the next word from the expected line,
+// following whichever of the line's alternatives the user has started typing.
+func (rs *ruleSet) nextWordHint(input, expected string) string {
+	expected = rs.prefixVariant(input, parseLyric(expected))
+	expectedWords := rs.words(expected)
+	inputWords := rs.words(input)
 
 	// If user is in the middle of typing a word (no trailing space), show that word
-	if len(input) > 0 && !strings.HasSuffix(input, " ") {
+	if rs.partialWord(input) {
 		wordIdx := len(inputWords) - 1
 		if wordIdx < len(expectedWords) {
 			return expectedWords[wordIdx]
@@ -201,57 +380,141 @@ func getNextWordHint(input, expected string) string {
 
 // parseSections extracts sections from lines. Each section starts with a # comment.
 // Lines before the first # are grouped into an "Intro" section if present.
+// Headers with more '#'s nest under the header before them with fewer, e.g.
+// "## Scene 1" under "# Act 1".
 func parseSections(lines []string) []section {
 	var sections []section
-	var currentSection *section
+	var open []int // enclosing sections, innermost last
 
 	for i, line := range lines {
 		if isComment(line) {
 			// Close previous section
-			if currentSection != nil {
-				currentSection.endIdx = i
-				sections = append(sections, *currentSection)
+			if len(sections) > 0 {
+				sections[len(sections)-1].endIdx = i
 			}
+			for len(open) > 0 && headerLevel(lines[sections[open[len(open)-1]].startIdx]) >= headerLevel(line) {
+				open = open[:len(open)-1]
+			}
+
 			// Start new section
-			currentSection = &section{
-				name:     headerText(line),
-				startIdx: i,
+			sec := section{name: headerText(line), startIdx: i, parent: -1, depth: len(open)}
+			if len(open) > 0 {
+				sec.parent = open[len(open)-1]
 			}
-		} else if currentSection == nil {
+			open = append(open, len(sections))
+			sections = append(sections, sec)
+		} else if len(sections) == 0 {
 			// Lines before first section header
-			currentSection = &section{
-				name:     "Intro",
-				startIdx: 0,
-			}
+			sections = append(sections, section{name: "Intro", startIdx: 0, parent: -1})
 		}
 	}
 
 	// Close final section
-	if currentSection != nil {
-		currentSection.endIdx = len(lines)
-		sections = append(sections, *currentSection)
+	if len(sections) > 0 {
+		sections[len(sections)-1].endIdx = len(lines)
 	}
 
 	return sections
 }
 
+// subtree returns section s followed by every section nested under it
+func subtree(sections []section, s int) []int {
+	tree := []int{s}
+	for i := s + 1; i < len(sections) && sections[i].depth > sections[s].depth; i++ {
+		tree = append(tree, i)
+	}
+	return tree
+}
+
+// sectionPath returns the name of section s with the names of the sections
+// it's nested under, e.g. "Act 1 / Scene 2"
+func sectionPath(sections []section, s int) string {
+	name := sections[s].name
+	for p := sections[s].parent; p >= 0; p = sections[p].parent {
+		name = sections[p].name + " / " + name
+	}
+	return name
+}
+
 func initialModel(meta metadata, lines []string) model {
 	sections := parseSections(lines)
 
+	rules := meta.rules
+	if rules == nil {
+		rules = defaultRules
+	}
+
 	return model{
-		meta:            meta,
-		allLines:        lines,
-		lines:           lines,
-		sections:        sections,
-		selectedSection: -1, // -1 means all sections
-		results:         make([]bool, len(lines)),
-		userInputs:      make([]string, len(lines)),
-		state:           stateSectionSelect,
+		meta:       meta,
+		rules:      rules,
+		allLines:   lines,
+		lines:      lines,
+		sections:   sections,
+		firstOf:    sameSections(lines, sections),
+		results:    make([]bool, len(lines)),
+		userInputs: make([]string, len(lines)),
+		hintsUsed:  make([]int, len(lines)),
+		scores:     make([]lineScore, len(lines)),
+		latencies:  make([]time.Duration, len(lines)),
+		timedOut:   make([]bool, len(lines)),
+		attempts:   make([]int, len(lines)),
+		clock:      time.Now,
+		rand:       rand.New(rand.NewSource(time.Now().UnixNano())),
+		state:      stateModeSelect,
+
+		shuffle:     meta.Recite.Shuffle,
+		once:        meta.Recite.Once,
+		hints:       meta.Recite.Hints,
+		hideHeaders: meta.Recite.HideHeader,
+	}
+}
+
+// expected returns what the user should type for line i in the current mode
+func (m model) expected(i int) string {
+	if m.mode == modeCloze {
+		return m.clozeAnswer(m.lines[i])
 	}
+	return m.lines[i]
 }
 
-// skipComments advances currentLine past any comment lines
+// beginRun starts practicing the selected lines in the current mode
+func (m *model) beginRun() tea.Cmd {
+	switch m.mode {
+	case modeQuiz:
+		m.state = stateQuiz
+		m.buildQuiz()
+	case modeReorder:
+		m.state = stateReorder
+		m.shuffleOrder()
+	case modeReverseChain:
+		m.state = stateTyping
+		m.currentLine = len(m.lines) - 1
+	case modeSection:
+		m.state = stateRecall
+	default:
+		m.state = stateTyping
+	}
+	m.skipComments()
+	if m.state == stateRecall {
+		m.newRecall()
+	}
+	return m.startRun()
+}
+
+// skipComments advances currentLine past any comment lines. A reverse
+// chain goes through the lines backwards.
 func (m *model) skipComments() {
+	if m.mode == modeReverseChain {
+		for m.currentLine >= 0 && isComment(m.lines[m.currentLine]) {
+			m.results[m.currentLine] = true
+			m.currentLine--
+		}
+		if m.currentLine < 0 {
+			m.state = stateResult
+		}
+		return
+	}
+
 	for m.currentLine < len(m.lines) && isComment(m.lines[m.currentLine]) {
 		m.results[m.currentLine] = true // Comments are always "correct"
 		m.currentLine++
@@ -261,7 +524,21 @@ func (m *model) skipComments() {
 	}
 }
 
+// nextLine moves on from the current line, backwards in a reverse chain
+func (m *model) nextLine() {
+	if m.mode == modeReverseChain {
+		m.currentLine--
+	} else {
+		m.currentLine++
+	}
+}
+
+// Init starts the clock when the command line options start the run
+// straight away
 func (m model) Init() tea.Cmd {
+	if m.state == stateTyping && m.isTimed() {
+		return tick(m.tickID)
+	}
 	return nil
 }
 
@@ -269,67 +546,191 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch m.state {
+		case stateModeSelect:
+			return m.handleModeSelectInput(msg)
 		case stateSectionSelect:
 			return m.handleSectionSelectInput(msg)
 		case stateTyping:
 			return m.handleTypingInput(msg)
+		case stateQuiz:
+			return m.handleQuizInput(msg)
+		case stateReorder:
+			return m.handleReorderInput(msg)
+		case stateRecall:
+			return m.handleRecallInput(msg)
 		case stateResult:
 			return m.handleResultInput(msg)
 		}
+
+	case tickMsg:
+		return m.handleTick(msg)
+
+	case historySavedMsg:
+		m.historyErr = msg.err
+
+	case reviewSavedMsg:
+		m.reviewErr = msg.err
 	}
 	return m, nil
 }
 
-// selectSection filters lines based on the selected section index.
-// Pass -1 to select all sections.
+// selectSection selects a single section, or every section for -1
 func (m *model) selectSection(sectionIdx int) {
-	m.selectedSection = sectionIdx
-
 	if sectionIdx < 0 || sectionIdx >= len(m.sections) {
-		// All sections
+		m.selectSections(nil)
+		return
+	}
+	m.selectSections([]int{sectionIdx})
+}
+
+// selectSections filters lines to the given sections along with the
+// sections nested under them, which are practiced in song order unless
+// shuffled. No sections, or every one, selects the whole song.
+func (m *model) selectSections(indices []int) {
+	m.selected = nil
+	for _, s := range indices {
+		if s < 0 || s >= len(m.sections) {
+			continue
+		}
+		for _, t := range subtree(m.sections, s) {
+			if !slices.Contains(m.selected, t) {
+				m.selected = append(m.selected, t)
+			}
+		}
+	}
+	slices.Sort(m.selected)
+	if len(m.selected) == len(m.sections) {
+		m.selected = nil
+	}
+
+	if m.selected == nil && !m.shuffles() && !(m.repeatsOnce() && m.hasRepeats()) {
 		m.lines = m.allLines
 		m.lineIndices = nil
-		m.results = make([]bool, len(m.lines))
-		m.userInputs = make([]string, len(m.lines))
 	} else {
-		// Specific section
-		sec := m.sections[sectionIdx]
-		m.lines = m.allLines[sec.startIdx:sec.endIdx]
-		m.lineIndices = make([]int, len(m.lines))
-		for i := range m.lines {
-			m.lineIndices[i] = sec.startIdx + i
+		order := slices.Clone(m.selected)
+		if order == nil {
+			for s := range m.sections {
+				order = append(order, s)
+			}
+		}
+		if m.shuffles() {
+			m.rand.Shuffle(len(order), func(a, b int) { order[a], order[b] = order[b], order[a] })
+		}
+
+		m.lines, m.lineIndices = nil, nil
+		sung := make(map[int]bool) // sections already sung, by firstOf
+		for _, s := range order {
+			sec := m.sections[s]
+			repeat := m.repeatsOnce() && sung[m.firstOf[s]]
+			sung[m.firstOf[s]] = true
+			for i := sec.startIdx; i < sec.endIdx; i++ {
+				m.lines = append(m.lines, m.allLines[i])
+				m.lineIndices = append(m.lineIndices, i)
+				// A repeat is cut short after its first line, which is
+				// enough to show it's sung here
+				if repeat && !isComment(m.allLines[i]) {
+					break
+				}
+			}
 		}
-		m.results = make([]bool, len(m.lines))
-		m.userInputs = make([]string, len(m.lines))
 	}
 
+	m.resetRun()
+	m.resetAttempts()
+}
+
+// shuffles returns true if the sections are practiced in random order.
+// Sing-along follows the track and reorder needs the song's own order.
+func (m model) shuffles() bool {
+	return m.shuffle && m.mode != modeSingAlong && m.mode != modeReorder
+}
+
+// repeatsOnce returns true if repeated sections are cut short. Like
+// shuffling, it's off for sing-along and reorder, which need whole sections.
+func (m model) repeatsOnce() bool {
+	return m.once && m.mode != modeSingAlong && m.mode != modeReorder
+}
+
+// resetRun clears the results of the current run so it can start over
+func (m *model) resetRun() {
 	m.currentLine = 0
-	m.input = ""
+	m.input.Reset()
+	m.hint = ""
+	m.hintLevel = 0
+	m.results = make([]bool, len(m.lines))
+	m.userInputs = make([]string, len(m.lines))
+	m.hintsUsed = make([]int, len(m.lines))
+	m.scores = make([]lineScore, len(m.lines))
+	m.latencies = make([]time.Duration, len(m.lines))
+	m.timedOut = make([]bool, len(m.lines))
+	m.elapsed = 0
+	m.historyErr = nil
 }
 
-func (m model) handleSectionSelectInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+// lineIndex returns the index in allLines of line i being practiced
+func (m model) lineIndex(i int) int {
+	if m.lineIndices == nil {
+		return i
+	}
+	return m.lineIndices[i]
+}
+
+// sectionName returns the names of the selected sections, e.g.
+// "Verse 1 + Chorus". Nested sections go by the section they're under.
+func (m model) sectionName() string {
+	if m.selected == nil {
+		return "All sections"
+	}
+	var names []string
+	for _, s := range m.selected {
+		if p := m.sections[s].parent; p < 0 || !slices.Contains(m.selected, p) {
+			names = append(names, m.sections[s].name)
+		}
+	}
+	return strings.Join(names, " + ")
+}
+
+// sectionAt returns the index of the section containing allLines[idx]
+func (m model) sectionAt(idx int) int {
+	for i, sec := range m.sections {
+		if idx >= sec.startIdx && idx < sec.endIdx {
+			return i
+		}
+	}
+	return -1
+}
+
+func (m model) handleModeSelectInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.Type {
 	case tea.KeyCtrlC, tea.KeyEsc:
 		return m, tea.Quit
 
+	case tea.KeyUp:
+		m.modeCursor = max(m.modeCursor-1, 0)
+		return m, nil
+
+	case tea.KeyDown:
+		m.modeCursor = min(m.modeCursor+1, len(m.availableModes())-1)
+		return m, nil
+
+	case tea.KeyEnter:
+		return m, m.chooseMode(m.availableModes()[m.modeCursor])
+
 	case tea.KeyRunes:
 		key := string(msg.Runes)
-		// "a" or "A" selects all sections
-		if key == "a" || key == "A" {
-			m.selectSection(-1)
-			m.state = stateTyping
-			m.skipComments()
+		switch key {
+		case "t", "T":
+			m.timed = !m.timed
+			return m, nil
+		case "l", "L":
+			m.cycleTimeLimit()
 			return m, nil
 		}
 
-		// Number keys 1-9 select specific sections
 		if len(key) == 1 && key[0] >= '1' && key[0] <= '9' {
-			idx := int(key[0] - '1') // Convert '1' to 0, '2' to 1, etc.
-			if idx < len(m.sections) {
-				m.selectSection(idx)
-				m.state = stateTyping
-				m.skipComments()
-				return m, nil
+			idx := int(key[0] - '1')
+			if available := m.availableModes(); idx < len(available) {
+				return m, m.chooseMode(available[idx])
 			}
 		}
 	}
@@ -343,51 +744,66 @@ func (m model) handleTypingInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, tea.Quit
 
 	case tea.KeyEnter:
-		// Check if input matches current line (ignoring punctuation, spaces, case, and g-dropping)
-		m.results[m.currentLine] = linesMatch(m.input, m.lines[m.currentLine])
-		m.userInputs[m.currentLine] = m.input
-		m.currentLine++
-		m.input = ""
-		m.hint = ""
-		m.hintLevel = 0
-
-		// Skip any comment lines
-		m.skipComments()
-		return m, nil
+		return m, m.submitLine()
 
 	case tea.KeyTab:
-		// First tab: show next word, second tab: show full line
+		// First tab: show next word, second tab: show full line, as far as
+		// the file allows
+		if m.hints == hintsOff {
+			return m, nil
+		}
 		if m.hintLevel == 0 {
-			m.hint = getNextWordHint(m.input, m.lines[m.currentLine])
+			m.hint = m.rulesFor(m.currentLine).nextWordHint(m.input.Value(), m.expected(m.currentLine))
 			m.hintLevel = 1
-		} else if m.hintLevel == 1 {
-			m.hint = m.lines[m.currentLine]
+		} else if m.hintLevel == 1 && m.hints == hintsOn {
+			m.hint = lyricText(m.expected(m.currentLine))
 			m.hintLevel = 2
 		}
 		return m, nil
 
-	case tea.KeyBackspace:
-		if len(m.input) > 0 {
-			m.input = m.input[:len(m.input)-1]
-		}
-		m.hint = ""
-		m.hintLevel = 0
-		return m, nil
+	}
 
-	case tea.KeyRunes:
-		m.input += string(msg.Runes)
+	// Anything else edits the input, which clears the hint
+	if m.input.update(msg) {
+		m.recordKeystroke()
 		m.hint = ""
 		m.hintLevel = 0
-		return m, nil
+	}
+	return m, nil
+}
 
-	case tea.KeySpace:
-		m.input += " "
-		m.hint = ""
-		m.hintLevel = 0
-		return m, nil
+// submitLine checks the input against the current line and moves on to the
+// next one. It returns the commands that save the run once it's complete.
+func (m *model) submitLine() tea.Cmd {
+	// Check if input matches current line (ignoring punctuation, spaces, case, and g-dropping)
+	input := m.input.Value()
+	m.results[m.currentLine] = m.rulesFor(m.currentLine).linesMatch(input, m.expected(m.currentLine))
+	if m.mode == modeSingAlong && m.timedOut[m.currentLine] {
+		// The song moved past the line before it was submitted
+		m.results[m.currentLine] = false
 	}
+	m.userInputs[m.currentLine] = input
+	m.hintsUsed[m.currentLine] = m.hintLevel
+	m.scores[m.currentLine] = m.rulesFor(m.currentLine).scoreLine(input, m.expected(m.currentLine), m.hintLevel)
+	m.recordLatency()
+	m.nextLine()
+	m.input.Reset()
+	m.hint = ""
+	m.hintLevel = 0
+
+	// Skip any comment lines
+	m.skipComments()
+	if m.state == stateResult {
+		return m.finishRun()
+	}
+	return nil
+}
 
-	return m, nil
+// finishRun records a completed run and returns the commands that save it
+func (m *model) finishRun() tea.Cmd {
+	m.gradeReview(m.clock())
+	m.countAttempts()
+	return tea.Batch(m.saveHistory(), m.saveReview())
 }
 
 func (m model) handleResultInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
@@ -399,13 +815,12 @@ func (m model) handleResultInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		key := string(msg.Runes)
 		if key == "y" || key == "Y" {
 			// Restart
-			m.currentLine = 0
-			m.input = ""
-			m.results = make([]bool, len(m.lines))
-			m.userInputs = make([]string, len(m.lines))
-			m.state = stateTyping
-			m.skipComments()
-			return m, nil
+			m.advanceCloze()
+			m.selectSections(m.selected)
+			return m, m.beginRun()
+		} else if (key == "r" || key == "R") && m.canRetryMissed() {
+			m.selectMissed()
+			return m, m.beginRun()
 		} else if key == "n" || key == "N" {
 			return m, tea.Quit
 		}
@@ -418,49 +833,85 @@ func (m model) View() string {
 	var b strings.Builder
 
 	switch m.state {
-	case stateSectionSelect:
+	case stateModeSelect:
 		b.WriteString("\n")
-		if m.meta.Title != "" {
-			b.WriteString(boldStyle.Render(m.meta.Title))
-			b.WriteString("\n")
-		}
-		if m.meta.Artist != "" {
-			b.WriteString(dimStyle.Render("by " + m.meta.Artist))
-			b.WriteString("\n")
+		m.writeIntro(&b)
+		b.WriteString(boldStyle.Render("Select Mode:"))
+		b.WriteString("\n\n")
+		available := m.availableModes()
+		for i, md := range available {
+			cursor := "  "
+			if i == m.modeCursor {
+				cursor = "> "
+			}
+			if i < 9 {
+				b.WriteString(fmt.Sprintf("%s%d. %s - %s\n", cursor, i+1, md, md.description()))
+			} else {
+				b.WriteString(fmt.Sprintf("%s   %s - %s\n", cursor, md, md.description()))
+			}
 		}
-		if m.meta.Title != "" || m.meta.Artist != "" {
-			b.WriteString("\n")
+		b.WriteString("\n")
+		timer, limit := "off", "none"
+		if m.timed {
+			timer = "on"
 		}
-		b.WriteString(boldStyle.Render("Select Section:"))
-		b.WriteString("\n\n")
-		b.WriteString("  a. All sections\n")
-		for i, sec := range m.sections {
-			b.WriteString(fmt.Sprintf("  %d. %s\n", i+1, sec.name))
+		if m.timeLimit > 0 {
+			limit = m.timeLimit.String()
 		}
+		b.WriteString(fmt.Sprintf("  t. Timer: %s\n", timer))
+		b.WriteString(fmt.Sprintf("  l. Time limit per line: %s\n", limit))
 		b.WriteString("\n")
-		b.WriteString("Press a or 1-9 to select: ")
+		b.WriteString(fmt.Sprintf("Press 1-%d or ↑/↓ and Enter to select, t or l to change timing: ", min(len(available), 9)))
+
+	case stateSectionSelect:
+		b.WriteString("\n")
+		m.writeIntro(&b)
+		m.writeSectionSelect(&b)
 
 	case stateTyping:
-		// Show previous lines with results
-		for i := 0; i < m.currentLine; i++ {
-			if isComment(m.lines[i]) {
+		m.writeClock(&b)
+
+		// Show previous lines with results, or in the chain modes just the
+		// neighbouring line as a cue
+		if m.mode.chained() {
+			m.writeChainCue(&b)
+		} else {
+			for i := 0; i < m.currentLine; i++ {
+				if isComment(m.lines[i]) && m.hideHeaders {
+					continue
+				}
+				if isComment(m.lines[i]) {
+					b.WriteString("\n")
+					b.WriteString(headerStyle.Render(headerText(m.lines[i])))
+				} else if m.results[i] {
+					b.WriteString(greenStyle.Render("✓ "))
+					b.WriteString(dimStyle.Render(lyricText(m.lines[i])))
+				} else {
+					b.WriteString(redStyle.Render("✗ "))
+					b.WriteString(m.rulesFor(i).formatDiff(m.userInputs[i], m.expected(i)))
+				}
 				b.WriteString("\n")
-				b.WriteString(headerStyle.Render(headerText(m.lines[i])))
-			} else if m.results[i] {
-				b.WriteString(greenStyle.Render("✓ "))
-				b.WriteString(dimStyle.Render(m.lines[i]))
-			} else {
-				b.WriteString(redStyle.Render("✗ "))
-				b.WriteString(formatDiff(m.userInputs[i], m.lines[i]))
 			}
-			b.WriteString("\n")
 		}
 
 		b.WriteString("\n")
 
+		// In practice mode the target line is shown above the input, and in
+		// the scaffolded modes it's shown with words blanked or shortened
+		switch m.mode {
+		case modePractice:
+			b.WriteString(dimStyle.Render(lyricText(m.lines[m.currentLine])))
+			b.WriteString("\n")
+		case modeCloze:
+			b.WriteString(m.clozePrompt(m.lines[m.currentLine]))
+			b.WriteString("\n")
+		case modeFirstLetter:
+			b.WriteString(dimStyle.Render(firstLetters(m.lines[m.currentLine])))
+			b.WriteString("\n")
+		}
+
 		// Show user input
-		b.WriteString(m.input)
-		b.WriteString("_") // Cursor
+		b.WriteString(m.input.View())
 		b.WriteString("\n")
 
 		// Show hint if available
@@ -469,64 +920,120 @@ func (m model) View() string {
 			b.WriteString("\n")
 		}
 
+	case stateQuiz:
+		m.writeQuiz(&b)
+
+	case stateReorder:
+		m.writeReorder(&b)
+
+	case stateRecall:
+		m.writeRecall(&b)
+
 	case stateResult:
 		// Show all lines with results
 		for i, line := range m.lines {
 			if isComment(line) {
 				b.WriteString("\n")
 				b.WriteString(headerStyle.Render(headerText(line)))
+				if m.mode == modeSection {
+					b.WriteString(m.sectionScore(i))
+				}
+			} else if m.mode.picksLines() {
+				m.writeAnswer(&b, i)
 			} else if m.results[i] {
 				b.WriteString(greenStyle.Render("✓ "))
-				b.WriteString(line)
+				b.WriteString(lyricText(line))
+				b.WriteString(m.lineTiming(i))
 			} else {
 				b.WriteString(redStyle.Render("✗ "))
-				b.WriteString(formatDiff(m.userInputs[i], m.lines[i]))
+				b.WriteString(m.rulesFor(i).formatDiff(m.userInputs[i], m.expected(i)))
+				b.WriteString(m.lineTiming(i))
 			}
 			b.WriteString("\n")
 		}
 
-		// Calculate score (excluding comments)
-		correct := 0
-		total := 0
-		for i, line := range m.lines {
-			if !isComment(line) {
-				total++
-				if m.results[i] {
-					correct++
-				}
+		score := m.runScore()
+		b.WriteString("\n")
+		b.WriteString(fmt.Sprintf("Score: %d/%d lines, %d%% (%s)\n", score.correct, score.total, score.percent(), m.mode))
+		m.writeTiming(&b)
+		if m.mode == modeCloze {
+			ratio := int(m.clozeRatio() * 100)
+			if m.passed() && m.clozeLevel < len(clozeRatios)-1 {
+				b.WriteString(fmt.Sprintf("Passed with %d%% blanked! Next time %d%% of words will be blanked.\n", ratio, int(clozeRatios[m.clozeLevel+1]*100)))
+			} else {
+				b.WriteString(fmt.Sprintf("%d%% of words blanked\n", ratio))
 			}
 		}
-
-		b.WriteString("\n")
-		b.WriteString(fmt.Sprintf("Score: %d/%d\n", correct, total))
+		if m.historyErr != nil {
+			b.WriteString(redStyle.Render("Could not save history: " + m.historyErr.Error()))
+			b.WriteString("\n")
+		}
+		if m.reviewErr != nil {
+			b.WriteString(redStyle.Render("Could not save review schedule: " + m.reviewErr.Error()))
+			b.WriteString("\n")
+		}
+		m.writeReviewSummary(&b)
+		m.writeAttempts(&b)
 		b.WriteString("\n")
+		if m.canRetryMissed() {
+			b.WriteString(fmt.Sprintf("Press r to retry just the %s.\n", plural(m.missed(), "missed line")))
+		}
 		b.WriteString("Try again? (y/n) ")
 	}
 
 	return b.String()
 }
 
-func main() {
-	if len(os.Args) < 2 {
-		fmt.Fprintln(os.Stderr, "Usage: recite <lyrics-file>")
-		os.Exit(1)
+// runScore totals the scores of the current run, excluding comments
+func (m model) runScore() runScore {
+	var score runScore
+	for i, line := range m.lines {
+		if !isComment(line) {
+			score.add(m.scores[i], m.results[i])
+		}
 	}
+	return score
+}
 
-	filename := os.Args[1]
-	meta, lines, err := readFile(filename)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
-		os.Exit(1)
+// writeAnswer writes the line picked for line i in the modes where lines
+// are picked rather than typed, with the right line in parentheses if it
+// was wrong
+func (m model) writeAnswer(b *strings.Builder, i int) {
+	if m.results[i] {
+		b.WriteString(greenStyle.Render("✓ "))
+		b.WriteString(lyricText(m.lines[i]))
+		return
 	}
+	b.WriteString(redStyle.Render("✗ "))
+	b.WriteString(strikeStyle.Render(m.userInputs[i]))
+	b.WriteString(" ")
+	b.WriteString(dimStyle.Render("(" + lyricText(m.lines[i]) + ")"))
+}
 
-	if len(lines) == 0 {
-		fmt.Fprintln(os.Stderr, "Error: file is empty")
-		os.Exit(1)
+// writeIntro writes the title, artist and other details from the front
+// matter, if any
+func (m model) writeIntro(b *strings.Builder) {
+	if m.meta.Title != "" {
+		b.WriteString(boldStyle.Render(m.meta.Title))
+		b.WriteString("\n")
 	}
+	if m.meta.Artist != "" {
+		b.WriteString(dimStyle.Render("by " + m.meta.Artist))
+		b.WriteString("\n")
+	}
+	details := m.meta.details()
+	for _, line := range details {
+		b.WriteString(dimStyle.Render(line))
+		b.WriteString("\n")
+	}
+	if m.meta.Title != "" || m.meta.Artist != "" || len(details) > 0 {
+		b.WriteString("\n")
+	}
+}
 
-	p := tea.NewProgram(initialModel(meta, lines))
-	if _, err := p.Run(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error running program: %v\n", err)
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 }
@@ -547,6 +1054,15 @@ func readFile(filename string) (metadata, []string, error) {
 		return metadata{}, nil, err
 	}
 
+	// LRC files are converted to the lyrics format with a timestamp per line
+	if strings.EqualFold(filepath.Ext(filename), ".lrc") {
+		meta, lines, err := parseLRC(allContent)
+		if err != nil {
+			return metadata{}, nil, fmt.Errorf("invalid LRC file: %w", err)
+		}
+		return meta, lines, nil
+	}
+
 	var meta metadata
 	var lines []string
 	startIdx := 0
@@ -566,6 +1082,12 @@ func readFile(filename string) (metadata, []string, error) {
 			// Parse YAML between the delimiters
 			yamlContent := strings.Join(allContent[1:endIdx], "\n")
 			if err := yaml.Unmarshal([]byte(yamlContent), &meta); err != nil {
+				return metadata{}, nil, fmt.Errorf("invalid YAML front matter: %w", frontMatterError(err))
+			}
+			if meta.Recite.Strictness != "" {
+				meta.Match.Strictness = meta.Recite.Strictness
+			}
+			if meta.rules, err = meta.Match.ruleSet(meta.Language); err != nil {
 				return metadata{}, nil, fmt.Errorf("invalid YAML front matter: %w", err)
 			}
 			startIdx = endIdx + 1
@@ -579,5 +1101,9 @@ func readFile(filename string) (metadata, []string, error) {
 		}
 	}
 
+	lines = expandRepeats(lines)
+	if err := meta.Recite.checkSection(parseSections(lines)); err != nil {
+		return metadata{}, nil, fmt.Errorf("invalid YAML front matter: %w", err)
+	}
 	return meta, lines, nil
 }
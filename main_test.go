@@ -2,12 +2,103 @@ package main
 
 import (
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
 
+func TestModeSelect(t *testing.T) {
+	t.Run("pressing 1 selects practice mode", func(t *testing.T) {
+		m := initialModel(metadata{}, []string{"Line one"})
+
+		newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'1'}})
+		m = newModel.(model)
+
+		if m.state != stateSectionSelect {
+			t.Errorf("state = %v, want stateSectionSelect", m.state)
+		}
+		if m.mode != modePractice {
+			t.Errorf("mode = %v, want %v", m.mode, modePractice)
+		}
+	})
+
+	t.Run("pressing 2 selects memory mode", func(t *testing.T) {
+		m := initialModel(metadata{}, []string{"Line one"})
+
+		newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'2'}})
+		m = newModel.(model)
+
+		if m.state != stateSectionSelect {
+			t.Errorf("state = %v, want stateSectionSelect", m.state)
+		}
+		if m.mode != modeMemory {
+			t.Errorf("mode = %v, want %v", m.mode, modeMemory)
+		}
+	})
+
+	t.Run("invalid mode number does nothing", func(t *testing.T) {
+		m := initialModel(metadata{}, []string{"Line one"})
+
+		newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'0'}})
+		m = newModel.(model)
+
+		if m.state != stateModeSelect {
+			t.Errorf("state = %v, want stateModeSelect (invalid number should do nothing)", m.state)
+		}
+	})
+
+	t.Run("arrow keys and enter select a mode", func(t *testing.T) {
+		m := initialModel(metadata{}, []string{"Line one"})
+		for _, k := range []tea.KeyType{tea.KeyDown, tea.KeyDown, tea.KeyUp, tea.KeyEnter} {
+			newModel, _ := m.Update(tea.KeyMsg{Type: k})
+			m = newModel.(model)
+		}
+
+		if m.state != stateSectionSelect {
+			t.Errorf("state = %v, want stateSectionSelect", m.state)
+		}
+		if m.mode != modeMemory {
+			t.Errorf("mode = %v, want %v", m.mode, modeMemory)
+		}
+	})
+
+	t.Run("cursor reaches modes past 9", func(t *testing.T) {
+		m := initialModel(metadata{}, []string{"[00:01.00] Line one"})
+		available := m.availableModes()
+		if len(available) <= 9 {
+			t.Skip("fewer than 10 modes")
+		}
+		for range available {
+			newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyDown})
+			m = newModel.(model)
+		}
+		if m.modeCursor != len(available)-1 {
+			t.Fatalf("modeCursor = %d, want the last mode", m.modeCursor)
+		}
+
+		view := m.View()
+		if !strings.Contains(view, "> ") || !strings.Contains(view, "   "+available[len(available)-1].String()+" - ") {
+			t.Errorf("view should list the last mode without a number, got: %s", view)
+		}
+
+		newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		if m = newModel.(model); m.mode != available[len(available)-1] {
+			t.Errorf("mode = %v, want %v", m.mode, available[len(available)-1])
+		}
+	})
+
+	t.Run("ctrl+c quits from mode select", func(t *testing.T) {
+		m := initialModel(metadata{}, []string{"Line one"})
+		_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlC})
+
+		if cmd == nil {
+			t.Error("expected quit command")
+		}
+	})
+}
+
 func TestSectionSelect(t *testing.T) {
 	t.Run("pressing a selects all sections", func(t *testing.T) {
 		m := initialModel(metadata{}, []string{"# Verse 1", "Line one", "# Chorus", "Line two"})
@@ -19,8 +110,8 @@ func TestSectionSelect(t *testing.T) {
 		if m.state != stateTyping {
 			t.Errorf("state = %v, want stateTyping", m.state)
 		}
-		if m.selectedSection != -1 {
-			t.Errorf("selectedSection = %d, want -1 (all sections)", m.selectedSection)
+		if m.selected != nil {
+			t.Errorf("selected = %v, want nil (all sections)", m.selected)
 		}
 		if len(m.lines) != 4 {
 			t.Errorf("len(lines) = %d, want 4", len(m.lines))
@@ -37,8 +128,8 @@ func TestSectionSelect(t *testing.T) {
 		if m.state != stateTyping {
 			t.Errorf("state = %v, want stateTyping", m.state)
 		}
-		if m.selectedSection != 0 {
-			t.Errorf("selectedSection = %d, want 0", m.selectedSection)
+		if len(m.selected) != 1 || m.selected[0] != 0 {
+			t.Errorf("selected = %v, want [0]", m.selected)
 		}
 		if len(m.lines) != 2 {
 			t.Errorf("len(lines) = %d, want 2 (Verse 1 header + Line one)", len(m.lines))
@@ -55,8 +146,8 @@ func TestSectionSelect(t *testing.T) {
 		if m.state != stateTyping {
 			t.Errorf("state = %v, want stateTyping", m.state)
 		}
-		if m.selectedSection != 1 {
-			t.Errorf("selectedSection = %d, want 1", m.selectedSection)
+		if len(m.selected) != 1 || m.selected[0] != 1 {
+			t.Errorf("selected = %v, want [1]", m.selected)
 		}
 		if len(m.lines) != 2 {
 			t.Errorf("len(lines) = %d, want 2 (Chorus header + Line two)", len(m.lines))
@@ -155,6 +246,55 @@ func TestParseSections(t *testing.T) {
 			t.Errorf("len(sections) = %d, want 0", len(sections))
 		}
 	})
+
+	t.Run("nests deeper headers", func(t *testing.T) {
+		lines := []string{
+			"# Act 1", "## Scene 1", "line a", "## Scene 2", "### Aside", "line b",
+			"# Act 2", "Prologue line", "## Scene 1", "line c",
+		}
+		sections := parseSections(lines)
+
+		want := []struct {
+			name          string
+			parent, depth int
+			path          string
+		}{
+			{"Act 1", -1, 0, "Act 1"},
+			{"Scene 1", 0, 1, "Act 1 / Scene 1"},
+			{"Scene 2", 0, 1, "Act 1 / Scene 2"},
+			{"Aside", 2, 2, "Act 1 / Scene 2 / Aside"},
+			{"Act 2", -1, 0, "Act 2"},
+			{"Scene 1", 4, 1, "Act 2 / Scene 1"},
+		}
+		if len(sections) != len(want) {
+			t.Fatalf("len(sections) = %d, want %d", len(sections), len(want))
+		}
+		for i, w := range want {
+			sec := sections[i]
+			if sec.name != w.name || sec.parent != w.parent || sec.depth != w.depth {
+				t.Errorf("sections[%d] = %q parent %d depth %d, want %q parent %d depth %d", i, sec.name, sec.parent, sec.depth, w.name, w.parent, w.depth)
+			}
+			if got := sectionPath(sections, i); got != w.path {
+				t.Errorf("sectionPath(%d) = %q, want %q", i, got, w.path)
+			}
+		}
+		if sections[4].startIdx != 6 || sections[4].endIdx != 8 {
+			t.Errorf("sections[4] range = [%d, %d), want [6, 8)", sections[4].startIdx, sections[4].endIdx)
+		}
+		if got := subtree(sections, 0); len(got) != 4 {
+			t.Errorf("subtree(0) = %v, want Act 1 and its 3 sections", got)
+		}
+		if got := subtree(sections, 3); len(got) != 1 {
+			t.Errorf("subtree(3) = %v, want just the aside", got)
+		}
+	})
+
+	t.Run("a shallower header after a deeper one is not nested", func(t *testing.T) {
+		sections := parseSections([]string{"## Verse", "a", "# Chorus", "b"})
+		if sections[1].parent != -1 {
+			t.Errorf("Chorus parent = %d, want -1", sections[1].parent)
+		}
+	})
 }
 
 func TestIsComment(t *testing.T) {
@@ -190,6 +330,7 @@ func TestHeaderText(t *testing.T) {
 		{"#  Multiple spaces", "Multiple spaces"},
 		{"# ", ""},
 		{"#", ""},
+		{"## Scene 2", "Scene 2"},
 	}
 
 	for _, tt := range tests {
@@ -270,6 +411,8 @@ func TestLinesMatch(t *testing.T) {
 		{"mixed g-dropping and punctuation", "dont stop believin", "don't stop believin'", true},
 		{"wrong word count", "hello", "hello world", false},
 		{"wrong words", "hello world", "goodbye world", false},
+		{"missing word", "up above world", "up above the world", false},
+		{"extra word", "up above the whole world", "up above the world", false},
 	}
 
 	for _, tt := range tests {
@@ -299,6 +442,9 @@ func TestNormalize(t *testing.T) {
 		{"a-b-c", "abc"},
 		{"(parentheses)", "parentheses"},
 		{"question?", "question"},
+		{"Cafe\u0301", "café"}, // combining accent composes
+		{"ＨＥＬＬＯ", "hello"},     // full-width letters
+		{"नमस्ते", "नमस्ते"},   // combining vowel signs are kept
 	}
 
 	for _, tt := range tests {
@@ -318,8 +464,8 @@ func TestInitialModel(t *testing.T) {
 		if m.currentLine != 0 {
 			t.Errorf("currentLine = %d, want 0", m.currentLine)
 		}
-		if m.state != stateSectionSelect {
-			t.Errorf("state = %v, want stateSectionSelect", m.state)
+		if m.state != stateModeSelect {
+			t.Errorf("state = %v, want stateModeSelect", m.state)
 		}
 		if len(m.results) != 2 {
 			t.Errorf("len(results) = %d, want 2", len(m.results))
@@ -331,7 +477,7 @@ func TestHandleTypingInput(t *testing.T) {
 	t.Run("correct input", func(t *testing.T) {
 		m := initialModel(metadata{}, []string{"Hello world", "Second line"})
 		m.state = stateTyping
-		m.input = "Hello world"
+		m.input.SetValue("Hello world")
 
 		newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
 		m = newModel.(model)
@@ -347,7 +493,7 @@ func TestHandleTypingInput(t *testing.T) {
 	t.Run("case insensitive comparison", func(t *testing.T) {
 		m := initialModel(metadata{}, []string{"Hello World"})
 		m.state = stateTyping
-		m.input = "hello world"
+		m.input.SetValue("hello world")
 
 		newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
 		m = newModel.(model)
@@ -360,7 +506,7 @@ func TestHandleTypingInput(t *testing.T) {
 	t.Run("incorrect input", func(t *testing.T) {
 		m := initialModel(metadata{}, []string{"Hello world"})
 		m.state = stateTyping
-		m.input = "Wrong input"
+		m.input.SetValue("Wrong input")
 
 		newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
 		m = newModel.(model)
@@ -373,7 +519,7 @@ func TestHandleTypingInput(t *testing.T) {
 	t.Run("whitespace trimming", func(t *testing.T) {
 		m := initialModel(metadata{}, []string{"Hello world"})
 		m.state = stateTyping
-		m.input = "  Hello world  "
+		m.input.SetValue("  Hello world  ")
 
 		newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
 		m = newModel.(model)
@@ -386,7 +532,7 @@ func TestHandleTypingInput(t *testing.T) {
 	t.Run("ignores punctuation", func(t *testing.T) {
 		m := initialModel(metadata{}, []string{"Don't stop believin'"})
 		m.state = stateTyping
-		m.input = "dont stop believin"
+		m.input.SetValue("dont stop believin")
 
 		newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
 		m = newModel.(model)
@@ -399,7 +545,7 @@ func TestHandleTypingInput(t *testing.T) {
 	t.Run("ignores extra spaces", func(t *testing.T) {
 		m := initialModel(metadata{}, []string{"Hello world"})
 		m.state = stateTyping
-		m.input = "Hello    world"
+		m.input.SetValue("Hello    world")
 
 		newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
 		m = newModel.(model)
@@ -412,7 +558,7 @@ func TestHandleTypingInput(t *testing.T) {
 	t.Run("g-dropping tolerance", func(t *testing.T) {
 		m := initialModel(metadata{}, []string{"Just a small town girl, livin' in a lonely world"})
 		m.state = stateTyping
-		m.input = "just a small town girl living in a lonely world"
+		m.input.SetValue("just a small town girl living in a lonely world")
 
 		newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
 		m = newModel.(model)
@@ -425,7 +571,7 @@ func TestHandleTypingInput(t *testing.T) {
 	t.Run("skips comments after enter", func(t *testing.T) {
 		m := initialModel(metadata{}, []string{"First line", "# Comment", "Third line"})
 		m.state = stateTyping
-		m.input = "First line"
+		m.input.SetValue("First line")
 
 		newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
 		m = newModel.(model)
@@ -438,7 +584,7 @@ func TestHandleTypingInput(t *testing.T) {
 	t.Run("transitions to result after last line", func(t *testing.T) {
 		m := initialModel(metadata{}, []string{"Only line"})
 		m.state = stateTyping
-		m.input = "Only line"
+		m.input.SetValue("Only line")
 
 		newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
 		m = newModel.(model)
@@ -451,46 +597,46 @@ func TestHandleTypingInput(t *testing.T) {
 	t.Run("backspace removes character", func(t *testing.T) {
 		m := initialModel(metadata{}, []string{"Test"})
 		m.state = stateTyping
-		m.input = "Hello"
+		m.input.SetValue("Hello")
 
 		newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyBackspace})
 		m = newModel.(model)
 
-		if m.input != "Hell" {
-			t.Errorf("input = %q, want %q", m.input, "Hell")
+		if m.input.Value() != "Hell" {
+			t.Errorf("input = %q, want %q", m.input.Value(), "Hell")
 		}
 	})
 
 	t.Run("typing adds characters", func(t *testing.T) {
 		m := initialModel(metadata{}, []string{"Test"})
 		m.state = stateTyping
-		m.input = "He"
+		m.input.SetValue("He")
 
 		newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'l', 'l'}})
 		m = newModel.(model)
 
-		if m.input != "Hell" {
-			t.Errorf("input = %q, want %q", m.input, "Hell")
+		if m.input.Value() != "Hell" {
+			t.Errorf("input = %q, want %q", m.input.Value(), "Hell")
 		}
 	})
 
 	t.Run("space adds space", func(t *testing.T) {
 		m := initialModel(metadata{}, []string{"Test"})
 		m.state = stateTyping
-		m.input = "Hello"
+		m.input.SetValue("Hello")
 
 		newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeySpace})
 		m = newModel.(model)
 
-		if m.input != "Hello " {
-			t.Errorf("input = %q, want %q", m.input, "Hello ")
+		if m.input.Value() != "Hello " {
+			t.Errorf("input = %q, want %q", m.input.Value(), "Hello ")
 		}
 	})
 
 	t.Run("tab shows hint for next word", func(t *testing.T) {
 		m := initialModel(metadata{}, []string{"Hello world today"})
 		m.state = stateTyping
-		m.input = "Hello "
+		m.input.SetValue("Hello ")
 
 		newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyTab})
 		m = newModel.(model)
@@ -506,7 +652,7 @@ func TestHandleTypingInput(t *testing.T) {
 	t.Run("double tab shows full line", func(t *testing.T) {
 		m := initialModel(metadata{}, []string{"Hello world today"})
 		m.state = stateTyping
-		m.input = "Hello "
+		m.input.SetValue("Hello ")
 
 		// First tab
 		newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyTab})
@@ -527,7 +673,7 @@ func TestHandleTypingInput(t *testing.T) {
 	t.Run("third tab does nothing", func(t *testing.T) {
 		m := initialModel(metadata{}, []string{"Hello world today"})
 		m.state = stateTyping
-		m.input = ""
+		m.input.SetValue("")
 
 		// First tab
 		newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyTab})
@@ -565,7 +711,7 @@ func TestHandleTypingInput(t *testing.T) {
 	t.Run("backspace clears hint", func(t *testing.T) {
 		m := initialModel(metadata{}, []string{"Hello world"})
 		m.state = stateTyping
-		m.input = "Hello"
+		m.input.SetValue("Hello")
 		m.hint = "Hello"
 
 		newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyBackspace})
@@ -579,7 +725,7 @@ func TestHandleTypingInput(t *testing.T) {
 	t.Run("enter clears hint", func(t *testing.T) {
 		m := initialModel(metadata{}, []string{"Hello", "World"})
 		m.state = stateTyping
-		m.input = "Hello"
+		m.input.SetValue("Hello")
 		m.hint = "Hello"
 
 		newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
@@ -695,6 +841,69 @@ func TestView(t *testing.T) {
 		}
 	})
 
+	t.Run("intro shows the other front matter", func(t *testing.T) {
+		meta := metadata{
+			Title: "Both Sides Now", Artist: "Joni Mitchell", Album: "Clouds", Year: "1969",
+			Key: "F", Tags: tagList{"folk", "standards"}, Source: "https://example.com/both-sides", Notes: "Capo 2",
+		}
+		view := initialModel(meta, []string{"Line one"}).View()
+
+		for _, want := range []string{"Clouds (1969) · Key of F", "Tags: folk, standards", "Source: https://example.com/both-sides", "Capo 2"} {
+			if !strings.Contains(view, want) {
+				t.Errorf("view should contain %q, got: %s", want, view)
+			}
+		}
+	})
+
+	t.Run("mode select shows modes", func(t *testing.T) {
+		m := initialModel(metadata{}, []string{"Line one"})
+		view := m.View()
+
+		if !strings.Contains(view, "Select Mode:") {
+			t.Error("view should show mode selection header")
+		}
+		if !strings.Contains(view, "1. Practice") {
+			t.Error("view should show practice mode")
+		}
+		if !strings.Contains(view, "2. Memory") {
+			t.Error("view should show memory mode")
+		}
+	})
+
+	t.Run("practice mode shows target line", func(t *testing.T) {
+		m := initialModel(metadata{}, []string{"Twinkle twinkle little star"})
+		m.state = stateTyping
+		m.mode = modePractice
+		view := m.View()
+
+		if !strings.Contains(view, "Twinkle twinkle little star") {
+			t.Error("practice mode should show the target line")
+		}
+	})
+
+	t.Run("memory mode hides target line", func(t *testing.T) {
+		m := initialModel(metadata{}, []string{"Twinkle twinkle little star"})
+		m.state = stateTyping
+		m.mode = modeMemory
+		view := m.View()
+
+		if strings.Contains(view, "Twinkle twinkle little star") {
+			t.Error("memory mode should not show the target line")
+		}
+	})
+
+	t.Run("result state shows mode", func(t *testing.T) {
+		m := initialModel(metadata{}, []string{"Line one"})
+		m.state = stateResult
+		m.mode = modeMemory
+		m.currentLine = 1
+		view := m.View()
+
+		if !strings.Contains(view, "(Memory)") {
+			t.Errorf("view should show score with mode, got: %s", view)
+		}
+	})
+
 	t.Run("typing state shows cursor", func(t *testing.T) {
 		m := initialModel(metadata{}, []string{"Test line"})
 		m.state = stateTyping
@@ -709,8 +918,8 @@ func TestView(t *testing.T) {
 		m := initialModel(metadata{}, []string{"# Comment", "Line one", "Line two"})
 		m.state = stateResult
 		m.currentLine = 3
-		m.results[0] = true // comment
-		m.results[1] = true // correct
+		m.results[0] = true  // comment
+		m.results[1] = true  // correct
 		m.results[2] = false // incorrect
 
 		view := m.View()
@@ -721,6 +930,21 @@ func TestView(t *testing.T) {
 		}
 	})
 
+	t.Run("result state shows percentage score", func(t *testing.T) {
+		m := initialModel(metadata{}, []string{"# Comment", "Line one", "Line two"})
+		m.state = stateResult
+		m.currentLine = 3
+		m.results[1] = true
+		m.scores[1] = scoreLine("Line one", "Line one", 0)
+		m.scores[2] = scoreLine("", "Line two", 0)
+
+		view := m.View()
+
+		if !strings.Contains(view, "Score: 1/2 lines, 50%") {
+			t.Errorf("view should show 'Score: 1/2 lines, 50%%', got: %s", view)
+		}
+	})
+
 	t.Run("result state shows try again prompt", func(t *testing.T) {
 		m := initialModel(metadata{}, []string{"Line one"})
 		m.state = stateResult
@@ -877,6 +1101,17 @@ func TestQuitCommands(t *testing.T) {
 	})
 }
 
+// writeLyrics writes a lyrics file to a temporary directory, returning its
+// path
+func writeLyrics(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "song.txt")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
 func TestReadFile(t *testing.T) {
 	t.Run("parses YAML front matter", func(t *testing.T) {
 		// Create temp file with front matter
@@ -887,17 +1122,9 @@ artist: John Newton
 How sweet the sound
 That saved a wretch like me
 `
-		f, err := os.CreateTemp("", "lyrics-*.txt")
-		if err != nil {
-			t.Fatal(err)
-		}
-		defer os.Remove(f.Name())
-		if _, err := f.WriteString(content); err != nil {
-			t.Fatal(err)
-		}
-		f.Close()
+		path := writeLyrics(t, content)
 
-		meta, lines, err := readFile(f.Name())
+		meta, lines, err := readFile(path)
 		if err != nil {
 			t.Fatalf("readFile error: %v", err)
 		}
@@ -920,17 +1147,9 @@ That saved a wretch like me
 		content := `Line one
 Line two
 `
-		f, err := os.CreateTemp("", "lyrics-*.txt")
-		if err != nil {
-			t.Fatal(err)
-		}
-		defer os.Remove(f.Name())
-		if _, err := f.WriteString(content); err != nil {
-			t.Fatal(err)
-		}
-		f.Close()
+		path := writeLyrics(t, content)
 
-		meta, lines, err := readFile(f.Name())
+		meta, lines, err := readFile(path)
 		if err != nil {
 			t.Fatalf("readFile error: %v", err)
 		}
@@ -951,17 +1170,9 @@ Line one
 
 Line two
 `
-		f, err := os.CreateTemp("", "lyrics-*.txt")
-		if err != nil {
-			t.Fatal(err)
-		}
-		defer os.Remove(f.Name())
-		if _, err := f.WriteString(content); err != nil {
-			t.Fatal(err)
-		}
-		f.Close()
+		path := writeLyrics(t, content)
 
-		_, lines, err := readFile(f.Name())
+		_, lines, err := readFile(path)
 		if err != nil {
 			t.Fatalf("readFile error: %v", err)
 		}
@@ -970,4 +1181,22 @@ Line two
 			t.Errorf("len(lines) = %d, want 2 (should skip empty lines)", len(lines))
 		}
 	})
+	t.Run("expands repeats", func(t *testing.T) {
+		_, lines, err := readFile(writeLyrics(t, "# Chorus\nLa la la\n# Verse\nOnce upon a time\n@Chorus\n"))
+		if err != nil {
+			t.Fatalf("readFile error: %v", err)
+		}
+		want := "# Chorus|La la la|# Verse|Once upon a time|# Chorus|La la la"
+		if got := strings.Join(lines, "|"); got != want {
+			t.Errorf("lines = %q, want %q", got, want)
+		}
+
+		_, lines, err = readFile(writeLyrics(t, "# Verse\nOnce\n@Bridge\n"))
+		if err != nil {
+			t.Fatalf("readFile error: %v", err)
+		}
+		if want := "# Verse|Once|@Bridge"; strings.Join(lines, "|") != want {
+			t.Errorf("lines = %q, want the unknown reference kept as a lyric", lines)
+		}
+	})
 }
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// tagList is a list of tags, written in front matter as a YAML list or a
+// comma separated string such as "folk, lullaby"
+type tagList []string
+
+func (t *tagList) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*t = nil
+		for _, tag := range strings.Split(value.Value, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				*t = append(*t, tag)
+			}
+		}
+		return nil
+	}
+	var tags []string
+	if err := value.Decode(&tags); err != nil {
+		return err
+	}
+	*t = tags
+	return nil
+}
+
+// year is the year a song is from, written as a number such as 1971 or
+// as text such as "1970s" or "c. 1806". It's shown as written and filtered
+// by the number in it.
+type year string
+
+func (y *year) UnmarshalYAML(value *yaml.Node) error {
+	*y = year(looseText(value, ", "))
+	return nil
+}
+
+// number returns the first number in the year, or 0 if it has none
+func (y year) number() int {
+	digits := strings.TrimLeftFunc(string(y), func(r rune) bool { return r < '0' || r > '9' })
+	if i := strings.IndexFunc(digits, func(r rune) bool { return r < '0' || r > '9' }); i >= 0 {
+		digits = digits[:i]
+	}
+	n, _ := strconv.Atoi(digits)
+	return n
+}
+
+// text is free text in the front matter. A list is taken as one item per
+// line, so a mistyped value is kept rather than stopping the file loading.
+type text string
+
+func (t *text) UnmarshalYAML(value *yaml.Node) error {
+	*t = text(looseText(value, "\n"))
+	return nil
+}
+
+// looseText returns a front matter value as text: a single value as
+// written, a list with its items joined by sep, and anything else as YAML
+func looseText(value *yaml.Node, sep string) string {
+	switch value.Kind {
+	case yaml.ScalarNode:
+		return strings.TrimSpace(value.Value)
+	case yaml.SequenceNode:
+		items := make([]string, len(value.Content))
+		for i, item := range value.Content {
+			items[i] = looseText(item, sep)
+		}
+		return strings.Join(items, sep)
+	}
+	out, err := yaml.Marshal(value)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// details returns the lines shown under the title and artist on the intro
+// screen, e.g. "Blue (1971) · Key of G"
+func (meta metadata) details() []string {
+	var about []string
+	switch {
+	case meta.Album != "" && meta.Year != "":
+		about = append(about, fmt.Sprintf("%s (%s)", meta.Album, meta.Year))
+	case meta.Album != "":
+		about = append(about, meta.Album)
+	case meta.Year != "":
+		about = append(about, string(meta.Year))
+	}
+	if meta.Key != "" {
+		about = append(about, "Key of "+meta.Key)
+	}
+	if meta.Language != "" {
+		about = append(about, "Language: "+meta.Language)
+	}
+
+	var lines []string
+	if len(about) > 0 {
+		lines = append(lines, strings.Join(about, " · "))
+	}
+	if len(meta.Tags) > 0 {
+		lines = append(lines, "Tags: "+strings.Join(meta.Tags, ", "))
+	}
+	if meta.Source != "" {
+		lines = append(lines, "Source: "+meta.Source)
+	}
+	if notes := strings.TrimSpace(string(meta.Notes)); notes != "" {
+		lines = append(lines, strings.Split(notes, "\n")...)
+	}
+	return lines
+}
+
+// extraFields returns the front matter keys recite doesn't use, as
+// "key: value" in key order
+func (meta metadata) extraFields() []string {
+	keys := make([]string, 0, len(meta.Extra))
+	for k := range meta.Extra {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	fields := make([]string, len(keys))
+	for i, k := range keys {
+		fields[i] = fmt.Sprintf("%s: %v", k, meta.Extra[k])
+	}
+	return fields
+}
+
+// libraryFilter picks out files in a library by their front matter. Empty
+// fields match everything.
+type libraryFilter struct {
+	artist   string // part of the artist's name
+	album    string // part of the album name
+	tag      string // one of the tags
+	language string // language tag
+	years    string // a year or range of years such as "1970-1979"
+}
+
+// matches returns true if the front matter passes the filter. Text is
+// compared ignoring case and punctuation.
+func (f libraryFilter) matches(meta metadata) (bool, error) {
+	if !strings.Contains(normalize(meta.Artist), normalize(f.artist)) ||
+		!strings.Contains(normalize(meta.Album), normalize(f.album)) {
+		return false, nil
+	}
+	if f.tag != "" && !slices.ContainsFunc(meta.Tags, func(tag string) bool { return normalize(tag) == normalize(f.tag) }) {
+		return false, nil
+	}
+	if f.language != "" && !strings.EqualFold(meta.Language, f.language) {
+		return false, nil
+	}
+	if f.years != "" {
+		from, to, err := parseYears(f.years)
+		if err != nil {
+			return false, err
+		}
+		if n := meta.Year.number(); n < from || n > to {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// parseYears parses a year such as "1971" or a range such as "1970-1979"
+func parseYears(s string) (int, int, error) {
+	a, b, found := strings.Cut(s, "-")
+	if !found {
+		b = a
+	}
+	from, errFrom := strconv.Atoi(strings.TrimSpace(a))
+	to, errTo := strconv.Atoi(strings.TrimSpace(b))
+	if errFrom != nil || errTo != nil || from > to {
+		return 0, 0, fmt.Errorf("invalid year %q, want a year or range such as 1970-1979", s)
+	}
+	return from, to, nil
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReadFileMetadata(t *testing.T) {
+	t.Run("reads every field", func(t *testing.T) {
+		path := writeLyrics(t, `---
+title: Both Sides Now
+artist: Joni Mitchell
+album: Clouds
+year: 1969
+tags: [folk, standards]
+language: en
+key: F
+source: https://example.com/both-sides
+notes: |
+  Capo 2
+  Slow
+---
+Rows and flows of angel hair
+`)
+		meta, _, err := readFile(path)
+		if err != nil {
+			t.Fatalf("readFile error: %v", err)
+		}
+		if meta.Album != "Clouds" || meta.Year != "1969" || meta.Key != "F" || meta.Language != "en" {
+			t.Errorf("meta = %+v", meta)
+		}
+		if !reflect.DeepEqual(meta.Tags, tagList{"folk", "standards"}) {
+			t.Errorf("Tags = %q, want folk and standards", meta.Tags)
+		}
+		if meta.Source != "https://example.com/both-sides" || meta.Notes != "Capo 2\nSlow" {
+			t.Errorf("Source = %q, Notes = %q", meta.Source, meta.Notes)
+		}
+		if len(meta.Extra) != 0 {
+			t.Errorf("Extra = %v, want none", meta.Extra)
+		}
+	})
+
+	t.Run("tags as a comma separated string", func(t *testing.T) {
+		meta, _, err := readFile(writeLyrics(t, "---\ntags: folk, , standards\n---\nline\n"))
+		if err != nil {
+			t.Fatalf("readFile error: %v", err)
+		}
+		if !reflect.DeepEqual(meta.Tags, tagList{"folk", "standards"}) {
+			t.Errorf("Tags = %q, want folk and standards", meta.Tags)
+		}
+	})
+
+	t.Run("unknown keys are kept", func(t *testing.T) {
+		meta, _, err := readFile(writeLyrics(t, "---\ntitle: Song\ncapo: 2\ntuning: DADGAD\n---\nline\n"))
+		if err != nil {
+			t.Fatalf("readFile error: %v", err)
+		}
+		if meta.Extra["capo"] != 2 || meta.Extra["tuning"] != "DADGAD" {
+			t.Errorf("Extra = %v, want capo and tuning", meta.Extra)
+		}
+		if got := meta.extraFields(); !reflect.DeepEqual(got, []string{"capo: 2", "tuning: DADGAD"}) {
+			t.Errorf("extraFields() = %q", got)
+		}
+	})
+
+	t.Run("loose years are kept as written", func(t *testing.T) {
+		for content, want := range map[string]year{
+			"year: '1971'":       "1971",
+			"year: 1970s":        "1970s",
+			"year: c. 1806":      "c. 1806",
+			"year: sometime":     "sometime",
+			"year: [1971, 1972]": "1971, 1972",
+		} {
+			meta, _, err := readFile(writeLyrics(t, "---\n"+content+"\n---\nline\n"))
+			if err != nil {
+				t.Fatalf("%s: readFile error: %v", content, err)
+			}
+			if meta.Year != want {
+				t.Errorf("%s: Year = %q, want %q", content, meta.Year, want)
+			}
+		}
+	})
+
+	t.Run("notes as a list", func(t *testing.T) {
+		meta, _, err := readFile(writeLyrics(t, "---\nnotes: [Capo 2, Slow]\n---\nline\n"))
+		if err != nil {
+			t.Fatalf("readFile error: %v", err)
+		}
+		if meta.Notes != "Capo 2\nSlow" {
+			t.Errorf("Notes = %q, want one item per line", meta.Notes)
+		}
+	})
+}
+
+func TestMetadataDetails(t *testing.T) {
+	tests := []struct {
+		name string
+		meta metadata
+		want []string
+	}{
+		{"nothing", metadata{Title: "Song", Artist: "Someone"}, nil},
+		{"album and year", metadata{Album: "Blue", Year: "1971"}, []string{"Blue (1971)"}},
+		{"year only", metadata{Year: "1971", Key: "G"}, []string{"1971 · Key of G"}},
+		{"year as text", metadata{Album: "Blue", Year: "1970s"}, []string{"Blue (1970s)"}},
+		{"language", metadata{Album: "Blue", Language: "ja"}, []string{"Blue · Language: ja"}},
+		{"tags, source and notes", metadata{Tags: tagList{"a", "b"}, Source: "book", Notes: "one\ntwo\n"}, []string{"Tags: a, b", "Source: book", "one", "two"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.meta.details(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("details() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLibraryFilter(t *testing.T) {
+	meta := metadata{Artist: "Joni Mitchell", Album: "Blue", Year: "1971", Tags: tagList{"Folk", "singer-songwriter"}, Language: "en"}
+
+	tests := []struct {
+		name    string
+		filter  libraryFilter
+		want    bool
+		wantErr bool
+	}{
+		{"no filter", libraryFilter{}, true, false},
+		{"part of the artist", libraryFilter{artist: "joni"}, true, false},
+		{"other artist", libraryFilter{artist: "Dylan"}, false, false},
+		{"album", libraryFilter{album: "blue"}, true, false},
+		{"tag ignores case and punctuation", libraryFilter{tag: "Singer Songwriter"}, true, false},
+		{"part of a tag", libraryFilter{tag: "singer"}, false, false},
+		{"language", libraryFilter{language: "EN"}, true, false},
+		{"other language", libraryFilter{language: "fr"}, false, false},
+		{"year", libraryFilter{years: "1971"}, true, false},
+		{"year range", libraryFilter{years: "1970-1979"}, true, false},
+		{"other years", libraryFilter{years: "1980-1989"}, false, false},
+		{"several fields", libraryFilter{artist: "mitchell", tag: "folk", years: "1971"}, true, false},
+		{"invalid year", libraryFilter{years: "seventies"}, false, true},
+		{"backwards range", libraryFilter{years: "1979-1970"}, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.filter.matches(meta)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("matches error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("matches = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("year written as text is filtered by its number", func(t *testing.T) {
+		got, err := libraryFilter{years: "1970-1979"}.matches(metadata{Year: "1970s"})
+		if err != nil || !got {
+			t.Errorf("matches = %v, err = %v, want a match", got, err)
+		}
+	})
+}
+
+func TestYearNumber(t *testing.T) {
+	tests := []struct {
+		year year
+		want int
+	}{
+		{"1971", 1971},
+		{"1970s", 1970},
+		{"c. 1806", 1806},
+		{"sometime", 0},
+		{"", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.year), func(t *testing.T) {
+			if got := tt.year.number(); got != tt.want {
+				t.Errorf("number() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// quizChoices is the most candidate lines offered for each quiz question,
+// including the right one
+const quizChoices = 4
+
+// quizQuestion asks which line follows prompt
+type quizQuestion struct {
+	prompt  string   // previous line of the song, empty at the start
+	choices []string // candidate next lines
+	answer  int      // index of the right line in choices
+}
+
+// buildQuiz creates a question for every lyric line being practiced. Wrong
+// choices are drawn from other lines of the song, preferring lines from the
+// same section since those are the easiest to mix up.
+func (m *model) buildQuiz() {
+	m.quiz = make([]quizQuestion, len(m.lines))
+	for i, line := range m.lines {
+		if isComment(line) {
+			continue
+		}
+		idx := m.lineIndex(i)
+
+		q := quizQuestion{prompt: m.previousLine(idx)}
+		q.choices = append([]string{lyricText(line)}, m.quizDistractors(idx, q.prompt)...)
+		m.rand.Shuffle(len(q.choices), func(a, b int) {
+			q.choices[a], q.choices[b] = q.choices[b], q.choices[a]
+		})
+		for c, choice := range q.choices {
+			if choice == lyricText(line) {
+				q.answer = c
+			}
+		}
+		m.quiz[i] = q
+	}
+}
+
+// previousLine returns the text of the lyric line before allLines[idx],
+// skipping section headers, or "" for the first line of the song
+func (m model) previousLine(idx int) string {
+	for j := idx - 1; j >= 0; j-- {
+		if !isComment(m.allLines[j]) {
+			return lyricText(m.allLines[j])
+		}
+	}
+	return ""
+}
+
+// quizDistractors returns up to quizChoices-1 wrong next lines for
+// allLines[idx]. Lines that also follow prompt somewhere else in the song
+// are right too, so they are never offered as wrong answers.
+func (m model) quizDistractors(idx int, prompt string) []string {
+	// Every line that follows the prompt is a right answer
+	exclude := map[string]bool{normalize(lyricText(m.allLines[idx])): true}
+	for j, line := range m.allLines {
+		if !isComment(line) && m.previousLine(j) == prompt {
+			exclude[normalize(lyricText(line))] = true
+		}
+	}
+
+	var same, other []string
+	for _, sec := range m.sections {
+		inSection := idx >= sec.startIdx && idx < sec.endIdx
+		for j := sec.startIdx; j < sec.endIdx; j++ {
+			line := m.allLines[j]
+			key := normalize(lyricText(line))
+			if isComment(line) || exclude[key] {
+				continue
+			}
+			exclude[key] = true
+			if inSection {
+				same = append(same, lyricText(line))
+			} else {
+				other = append(other, lyricText(line))
+			}
+		}
+	}
+
+	m.rand.Shuffle(len(same), func(a, b int) { same[a], same[b] = same[b], same[a] })
+	m.rand.Shuffle(len(other), func(a, b int) { other[a], other[b] = other[b], other[a] })
+	candidates := append(same, other...)
+	return candidates[:min(len(candidates), quizChoices-1)]
+}
+
+func (m model) handleQuizInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyCtrlC, tea.KeyEsc:
+		return m, tea.Quit
+
+	case tea.KeyRunes:
+		key := string(msg.Runes)
+		if len(key) == 1 && key[0] >= '1' && key[0] <= '9' {
+			if choice := int(key[0] - '1'); choice < len(m.quiz[m.currentLine].choices) {
+				return m, m.answerQuiz(choice)
+			}
+		}
+	}
+
+	return m, nil
+}
+
+// answerQuiz records the choice for the current question and moves on to
+// the next one. It returns the commands that save the run once it's
+// complete.
+func (m *model) answerQuiz(choice int) tea.Cmd {
+	q := m.quiz[m.currentLine]
+	correct := choice == q.answer
+	m.results[m.currentLine] = correct
+	m.userInputs[m.currentLine] = q.choices[choice]
+	m.scores[m.currentLine] = allOrNothing(correct)
+	m.currentLine++
+
+	m.skipComments()
+	if m.state == stateResult {
+		return m.finishRun()
+	}
+	return nil
+}
+
+// questionNumber returns the number of the current question and the total
+// number of questions
+func (m model) questionNumber() (int, int) {
+	n, total := 0, 0
+	for i, line := range m.lines {
+		if isComment(line) {
+			continue
+		}
+		total++
+		if i <= m.currentLine {
+			n++
+		}
+	}
+	return n, total
+}
+
+// writeQuiz writes the current question and its choices
+func (m model) writeQuiz(b *strings.Builder) {
+	q := m.quiz[m.currentLine]
+	n, total := m.questionNumber()
+	b.WriteString(dimStyle.Render(fmt.Sprintf("Question %d of %d", n, total)))
+	b.WriteString("\n\n")
+
+	if q.prompt == "" {
+		b.WriteString(dimStyle.Render("(start of song)"))
+	} else {
+		b.WriteString(q.prompt)
+	}
+	b.WriteString("\n")
+	b.WriteString(boldStyle.Render("What comes next?"))
+	b.WriteString("\n\n")
+
+	for i, choice := range q.choices {
+		b.WriteString(fmt.Sprintf("  %d. %s\n", i+1, choice))
+	}
+	b.WriteString("\n")
+	b.WriteString(fmt.Sprintf("Press 1-%d to answer: ", len(q.choices)))
+
+	if m.currentLine > 0 {
+		// Feedback on the last answer
+		last := m.currentLine - 1
+		for last > 0 && isComment(m.lines[last]) {
+			last--
+		}
+		if !isComment(m.lines[last]) {
+			b.WriteString("\n\n")
+			m.writeAnswer(b, last)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestQuiz(t *testing.T) {
+	lines := []string{
+		"# Verse",
+		"Twinkle twinkle little star",
+		"How I wonder what you are",
+		"Up above the world so high",
+		"Like a diamond in the sky",
+		"# Chorus",
+		"Twinkle twinkle little star",
+		"How I wonder what you are",
+		"# Bridge",
+		"When the blazing sun is gone",
+		"When he nothing shines upon",
+	}
+
+	newQuiz := func(section int) model {
+		m := initialModel(metadata{}, lines)
+		m.rand = rand.New(rand.NewSource(1))
+		m.mode = modeQuiz
+		m.selectSection(section)
+		m.beginRun()
+		return m
+	}
+
+	// answer picks the right choice, or a wrong one if right is false
+	answer := func(m model, right bool) model {
+		q := m.quiz[m.currentLine]
+		choice := q.answer
+		if !right {
+			choice = (q.answer + 1) % len(q.choices)
+		}
+		newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{rune('1' + choice)}})
+		return newModel.(model)
+	}
+
+	t.Run("starts a quiz on section select", func(t *testing.T) {
+		m := newQuiz(-1)
+		if m.state != stateQuiz {
+			t.Fatalf("state = %v, want stateQuiz", m.state)
+		}
+		if m.currentLine != 1 {
+			t.Errorf("currentLine = %d, want 1 (skip header)", m.currentLine)
+		}
+	})
+
+	t.Run("offers the next line among other lines", func(t *testing.T) {
+		m := newQuiz(-1)
+		for i, q := range m.quiz {
+			if isComment(lines[i]) {
+				continue
+			}
+			if q.choices[q.answer] != lines[i] {
+				t.Errorf("line %d: answer = %q, want %q", i, q.choices[q.answer], lines[i])
+			}
+			if len(q.choices) != quizChoices {
+				t.Errorf("line %d: %d choices, want %d", i, len(q.choices), quizChoices)
+			}
+			seen := make(map[string]bool)
+			for _, c := range q.choices {
+				if seen[c] {
+					t.Errorf("line %d: duplicate choice %q", i, c)
+				}
+				seen[c] = true
+			}
+		}
+	})
+
+	t.Run("shows the previous line as the prompt", func(t *testing.T) {
+		m := newQuiz(-1)
+		if got := m.quiz[1].prompt; got != "" {
+			t.Errorf("first prompt = %q, want start of song", got)
+		}
+		if got := m.quiz[9].prompt; got != "How I wonder what you are" {
+			t.Errorf("prompt across sections = %q, want last line of the chorus", got)
+		}
+	})
+
+	t.Run("prefers lines from the same section", func(t *testing.T) {
+		m := newQuiz(2) // Bridge
+		q := m.quiz[2]
+		for _, c := range q.choices {
+			if c == "When the blazing sun is gone" {
+				return
+			}
+		}
+		t.Errorf("choices %q should include the other bridge line", q.choices)
+	})
+
+	t.Run("never offers another right answer as wrong", func(t *testing.T) {
+		m := newQuiz(-1)
+		// "Up above the world so high" and "When the blazing sun is gone" both
+		// follow "How I wonder what you are"
+		for _, i := range []int{3, 9} {
+			q := m.quiz[i]
+			for c, choice := range q.choices {
+				if c == q.answer {
+					continue
+				}
+				if choice == "Up above the world so high" || choice == "When the blazing sun is gone" {
+					t.Errorf("line %d: %q is also a right answer", i, choice)
+				}
+			}
+		}
+	})
+
+	t.Run("view shows prompt and choices", func(t *testing.T) {
+		m := newQuiz(-1)
+		m = answer(m, true)
+
+		view := m.View()
+		q := m.quiz[m.currentLine]
+		if !strings.Contains(view, "Twinkle twinkle little star\n") || !strings.Contains(view, "What comes next?") {
+			t.Errorf("view should show the previous line, got: %s", view)
+		}
+		for i, c := range q.choices {
+			if !strings.Contains(view, string(rune('1'+i))+". "+c) {
+				t.Errorf("view should show choice %q, got: %s", c, view)
+			}
+		}
+		if !strings.Contains(view, "Question 2 of 8") {
+			t.Errorf("view should show progress, got: %s", view)
+		}
+	})
+
+	t.Run("scores answers", func(t *testing.T) {
+		m := newQuiz(1) // Chorus
+		m = answer(m, true)
+		m = answer(m, false)
+
+		if m.state != stateResult {
+			t.Fatalf("state = %v, want stateResult", m.state)
+		}
+		if !m.results[1] || m.results[2] {
+			t.Errorf("results = %v, want right then wrong", m.results)
+		}
+
+		view := m.View()
+		if !strings.Contains(view, "Score: 1/2 lines, 50% (Quiz)") {
+			t.Errorf("view should show quiz score, got: %s", view)
+		}
+		if !strings.Contains(view, "(How I wonder what you are)") {
+			t.Errorf("view should show the right line for a wrong answer, got: %s", view)
+		}
+	})
+
+	t.Run("invalid choice does nothing", func(t *testing.T) {
+		m := newQuiz(-1)
+		newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'9'}})
+		if m = newModel.(model); m.currentLine != 1 {
+			t.Errorf("currentLine = %d, want 1", m.currentLine)
+		}
+	})
+
+	t.Run("retry builds a new quiz", func(t *testing.T) {
+		m := newQuiz(1)
+		m = answer(m, true)
+		m = answer(m, true)
+
+		newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}})
+		if m = newModel.(model); m.state != stateQuiz {
+			t.Errorf("state = %v, want stateQuiz", m.state)
+		}
+	})
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/cursor"
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// recallWidth is the width of the text area a section is typed into
+const recallWidth = 72
+
+// sectionEnd returns the index of the line after the last lyric line of the
+// section that line i is in
+func (m model) sectionEnd(i int) int {
+	for i < len(m.lines) && !isComment(m.lines[i]) {
+		i++
+	}
+	return i
+}
+
+// newRecall sets up an empty text area for the section at the current line
+func (m *model) newRecall() {
+	m.recall = textarea.New()
+	m.recall.ShowLineNumbers = false
+	m.recall.SetWidth(recallWidth)
+	m.recall.SetHeight(max(m.sectionEnd(m.currentLine)-m.currentLine+1, 3))
+	m.recall.Cursor.SetMode(cursor.CursorStatic)
+	m.recall.Focus()
+}
+
+func (m model) handleRecallInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyCtrlC, tea.KeyEsc:
+		return m, tea.Quit
+
+	case tea.KeyCtrlD:
+		return m, m.submitSection()
+	}
+
+	var cmd tea.Cmd
+	m.recall, cmd = m.recall.Update(msg)
+	return m, cmd
+}
+
+// submitSection checks what was typed for the current section line by line
+// and moves on to the next section. It returns the commands that save the
+// run once it's complete.
+func (m *model) submitSection() tea.Cmd {
+	end := m.sectionEnd(m.currentLine)
+	inputs := m.rulesFor(m.currentLine).alignLines(m.recall.Value(), m.lines[m.currentLine:end])
+	for k, input := range inputs {
+		i := m.currentLine + k
+		m.results[i] = m.rulesFor(i).linesMatch(input, m.expected(i))
+		m.userInputs[i] = input
+		m.scores[i] = m.rulesFor(i).scoreLine(input, m.expected(i), 0)
+	}
+	m.currentLine = end
+
+	m.skipComments()
+	if m.state == stateResult {
+		return m.finishRun()
+	}
+	m.newRecall()
+	return nil
+}
+
+// sectionScore returns the score of the lines under the header at line i,
+// shown next to the header on the result screen
+func (m model) sectionScore(i int) string {
+	var score runScore
+	for j := i + 1; j < m.sectionEnd(i+1); j++ {
+		score.add(m.scores[j], m.results[j])
+	}
+	if score.total == 0 {
+		return ""
+	}
+	return dimStyle.Render(fmt.Sprintf("  %d/%d lines, %d%%", score.correct, score.total, score.percent()))
+}
+
+// writeRecall writes the header of the current section and the text area
+// it's typed into
+func (m model) writeRecall(b *strings.Builder) {
+	if m.currentLine > 0 && isComment(m.lines[m.currentLine-1]) && !m.hideHeaders {
+		b.WriteString(headerStyle.Render(headerText(m.lines[m.currentLine-1])))
+		b.WriteString("\n")
+	}
+	lines := m.sectionEnd(m.currentLine) - m.currentLine
+	b.WriteString(dimStyle.Render(fmt.Sprintf("Type all %s, pressing Enter between them. Press Ctrl+D to check.", plural(lines, "line"))))
+	b.WriteString("\n\n")
+	b.WriteString(m.recall.View())
+	b.WriteString("\n")
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestAlignLines(t *testing.T) {
+	expected := []string{
+		"Twinkle twinkle little star",
+		"How I wonder what you are",
+		"Up above the world so high",
+	}
+
+	for _, tt := range []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{
+			name:  "one typed line per line",
+			input: "Twinkle twinkle little star\nHow I wonder what you are\nUp above the world so high",
+			want:  expected,
+		},
+		{
+			name:  "merged lines",
+			input: "Twinkle twinkle little star how I wonder what you are\nUp above the world so high",
+			want:  []string{"Twinkle twinkle little star", "how I wonder what you are", "Up above the world so high"},
+		},
+		{
+			name:  "split line",
+			input: "Twinkle twinkle\nlittle star\nHow I wonder what you are\nUp above the world so high",
+			want:  expected,
+		},
+		{
+			name:  "missing line",
+			input: "Twinkle twinkle little star\nUp above the world so high",
+			want:  []string{"Twinkle twinkle little star", "", "Up above the world so high"},
+		},
+		{
+			name:  "wrong and extra words",
+			input: "Twinkle twinkle little star\nHow I wonder what you are oh\nUp above the world so low",
+			want:  []string{"Twinkle twinkle little star", "How I wonder what you are oh", "Up above the world so low"},
+		},
+		{
+			name:  "nothing typed",
+			input: "",
+			want:  []string{"", "", ""},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got := defaultRules.alignLines(tt.input, expected)
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("alignLines() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("phrase variants", func(t *testing.T) {
+		got := defaultRules.alignLines("I'm gonna be there for you", []string{"I'm going to be there", "for you"})
+		if got[0] != "I'm gonna be there" || got[1] != "for you" {
+			t.Errorf("alignLines() = %q", got)
+		}
+	})
+}
+
+func TestSectionRecall(t *testing.T) {
+	lines := []string{
+		"# Verse",
+		"Twinkle twinkle little star",
+		"How I wonder what you are",
+		"# Chorus",
+		"Up above the world so high",
+	}
+
+	newRecall := func(section int) model {
+		m := initialModel(metadata{}, lines)
+		m.mode = modeSection
+		m.selectSection(section)
+		m.beginRun()
+		return m
+	}
+
+	// typeText types s into the text area, with Enter for each newline
+	typeText := func(m model, s string) model {
+		for i, line := range strings.Split(s, "\n") {
+			if i > 0 {
+				newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+				m = newModel.(model)
+			}
+			newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(line)})
+			m = newModel.(model)
+		}
+		return m
+	}
+
+	submit := func(m model) model {
+		newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyCtrlD})
+		return newModel.(model)
+	}
+
+	t.Run("shows the section header and text area", func(t *testing.T) {
+		m := newRecall(-1)
+		if m.state != stateRecall {
+			t.Fatalf("state = %v, want stateRecall", m.state)
+		}
+		view := m.View()
+		if !strings.Contains(view, "Verse") || !strings.Contains(view, "Type all 2 lines") {
+			t.Errorf("view should show the section, got: %s", view)
+		}
+		if strings.Contains(view, "Twinkle") {
+			t.Errorf("view should not show the lyrics, got: %s", view)
+		}
+	})
+
+	t.Run("enter adds a line instead of submitting", func(t *testing.T) {
+		m := typeText(newRecall(0), "Twinkle twinkle little star\nHow I")
+		if m.state != stateRecall {
+			t.Fatalf("state = %v, want stateRecall", m.state)
+		}
+		if got := m.recall.Value(); got != "Twinkle twinkle little star\nHow I" {
+			t.Errorf("value = %q", got)
+		}
+	})
+
+	t.Run("ctrl+d checks each line", func(t *testing.T) {
+		m := typeText(newRecall(0), "Twinkle twinkle little star how I wonder what you were")
+		m = submit(m)
+
+		if m.state != stateResult {
+			t.Fatalf("state = %v, want stateResult", m.state)
+		}
+		if !m.results[1] || m.results[2] {
+			t.Errorf("results = %v, want first line right and second wrong", m.results)
+		}
+
+		view := m.View()
+		if !strings.Contains(view, "were") || !strings.Contains(view, "(are)") {
+			t.Errorf("view should show the diff for the wrong line, got: %s", view)
+		}
+		if !strings.Contains(view, "Verse") || !strings.Contains(view, "1/2 lines") {
+			t.Errorf("view should show the section score, got: %s", view)
+		}
+	})
+
+	t.Run("all sections go one section at a time", func(t *testing.T) {
+		m := typeText(newRecall(-1), "Twinkle twinkle little star\nHow I wonder what you are")
+		m = submit(m)
+
+		if m.state != stateRecall || m.currentLine != 4 {
+			t.Fatalf("state = %v, currentLine = %d, want the chorus", m.state, m.currentLine)
+		}
+		if m.recall.Value() != "" {
+			t.Errorf("text area should be cleared, got %q", m.recall.Value())
+		}
+		if view := m.View(); !strings.Contains(view, "Chorus") {
+			t.Errorf("view should show the next section, got: %s", view)
+		}
+
+		m = submit(typeText(m, "Up above the world so high"))
+		if m.state != stateResult || m.runScore().correct != 3 {
+			t.Errorf("state = %v, results = %v, want every line right", m.state, m.results)
+		}
+	})
+}
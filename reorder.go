@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// orderLines returns the indices of the lyric lines being practiced, in
+// file order
+func (m model) orderLines() []int {
+	var order []int
+	for i, line := range m.lines {
+		if !isComment(line) {
+			order = append(order, i)
+		}
+	}
+	return order
+}
+
+// shuffleOrder shuffles the lyric lines for a reorder drill, making sure
+// they don't start out in order when that's possible
+func (m *model) shuffleOrder() {
+	m.order = m.orderLines()
+	m.orderCursor = 0
+	m.orderHeld = false
+	for attempt := 0; attempt < 10; attempt++ {
+		m.rand.Shuffle(len(m.order), func(a, b int) {
+			m.order[a], m.order[b] = m.order[b], m.order[a]
+		})
+		if m.misplaced() > 0 {
+			return
+		}
+	}
+}
+
+// misplaced returns the number of lines not in their original position.
+// Repeated lines are interchangeable.
+func (m model) misplaced() int {
+	n := 0
+	for pos, i := range m.orderLines() {
+		if normalize(lyricText(m.lines[m.order[pos]])) != normalize(lyricText(m.lines[i])) {
+			n++
+		}
+	}
+	return n
+}
+
+func (m model) handleReorderInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyCtrlC, tea.KeyEsc:
+		return m, tea.Quit
+
+	case tea.KeyUp:
+		m.moveCursor(-1)
+
+	case tea.KeyDown:
+		m.moveCursor(1)
+
+	case tea.KeySpace:
+		m.orderHeld = !m.orderHeld
+
+	case tea.KeyEnter:
+		return m, m.submitOrder()
+	}
+
+	return m, nil
+}
+
+// moveCursor moves the cursor by delta, taking the held line along with it
+func (m *model) moveCursor(delta int) {
+	to := m.orderCursor + delta
+	if to < 0 || to >= len(m.order) {
+		return
+	}
+	if m.orderHeld {
+		m.order[m.orderCursor], m.order[to] = m.order[to], m.order[m.orderCursor]
+	}
+	m.orderCursor = to
+}
+
+// submitOrder scores each position of the arranged lines against the
+// original order and shows the result. It returns the commands that save
+// the run.
+func (m *model) submitOrder() tea.Cmd {
+	for pos, i := range m.orderLines() {
+		placed := lyricText(m.lines[m.order[pos]])
+		correct := normalize(placed) == normalize(lyricText(m.lines[i]))
+		m.results[i] = correct
+		m.userInputs[i] = placed
+		m.scores[i] = allOrNothing(correct)
+	}
+	m.state = stateResult
+	return m.finishRun()
+}
+
+// writeReorder writes the lines in their current arrangement
+func (m model) writeReorder(b *strings.Builder) {
+	b.WriteString(boldStyle.Render(fmt.Sprintf("Put the lines in order (%s):", m.sectionName())))
+	b.WriteString("\n\n")
+
+	for pos, i := range m.order {
+		text := lyricText(m.lines[i])
+		switch {
+		case pos == m.orderCursor && m.orderHeld:
+			b.WriteString("» " + boldStyle.Render(text))
+		case pos == m.orderCursor:
+			b.WriteString("> " + text)
+		default:
+			b.WriteString("  " + text)
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+	b.WriteString(dimStyle.Render("↑/↓ to move, space to pick up or drop a line, enter when done"))
+}
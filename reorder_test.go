@@ -0,0 +1,132 @@
+package main
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestReorder(t *testing.T) {
+	lines := []string{
+		"# Verse",
+		"Twinkle twinkle little star",
+		"How I wonder what you are",
+		"Up above the world so high",
+		"# Chorus",
+		"Like a diamond in the sky",
+	}
+
+	newReorder := func(section int) model {
+		m := initialModel(metadata{}, lines)
+		m.rand = rand.New(rand.NewSource(1))
+		m.mode = modeReorder
+		m.selectSection(section)
+		m.beginRun()
+		return m
+	}
+
+	press := func(m model, keys ...tea.KeyType) model {
+		for _, k := range keys {
+			newModel, _ := m.Update(tea.KeyMsg{Type: k})
+			m = newModel.(model)
+		}
+		return m
+	}
+
+	t.Run("shuffles the lines of the section", func(t *testing.T) {
+		m := newReorder(0)
+		if m.state != stateReorder {
+			t.Fatalf("state = %v, want stateReorder", m.state)
+		}
+		if len(m.order) != 3 {
+			t.Fatalf("order = %v, want the 3 verse lines", m.order)
+		}
+		if m.misplaced() == 0 {
+			t.Errorf("order = %v, should start shuffled", m.order)
+		}
+	})
+
+	t.Run("leaves out section headers", func(t *testing.T) {
+		m := newReorder(-1)
+		for _, i := range m.order {
+			if isComment(m.lines[i]) {
+				t.Errorf("order should not include header %q", m.lines[i])
+			}
+		}
+	})
+
+	t.Run("arrow keys move the cursor", func(t *testing.T) {
+		m := newReorder(0)
+		before := append([]int(nil), m.order...)
+
+		m = press(m, tea.KeyDown, tea.KeyDown, tea.KeyDown, tea.KeyUp)
+		if m.orderCursor != 1 {
+			t.Errorf("orderCursor = %d, want 1", m.orderCursor)
+		}
+		for i := range before {
+			if m.order[i] != before[i] {
+				t.Fatal("moving the cursor should not change the order")
+			}
+		}
+	})
+
+	t.Run("space picks up a line to move", func(t *testing.T) {
+		m := newReorder(0)
+		first := m.order[0]
+
+		m = press(m, tea.KeySpace, tea.KeyDown, tea.KeyDown, tea.KeySpace, tea.KeyUp)
+		if m.order[2] != first {
+			t.Errorf("order = %v, want line %d moved to the end", m.order, first)
+		}
+		if m.orderCursor != 1 {
+			t.Errorf("orderCursor = %d, want 1", m.orderCursor)
+		}
+	})
+
+	t.Run("scores lines in the right position", func(t *testing.T) {
+		m := newReorder(0)
+		m.order = []int{1, 3, 2}
+
+		m = press(m, tea.KeyEnter)
+		if m.state != stateResult {
+			t.Fatalf("state = %v, want stateResult", m.state)
+		}
+		if !m.results[1] || m.results[2] || m.results[3] {
+			t.Errorf("results = %v, want only the first line right", m.results)
+		}
+
+		view := m.View()
+		if !strings.Contains(view, "Score: 1/3 lines, 33% (Reorder)") {
+			t.Errorf("view should show score, got: %s", view)
+		}
+		if !strings.Contains(view, "(How I wonder what you are)") {
+			t.Errorf("view should show the right line for a misplaced one, got: %s", view)
+		}
+	})
+
+	t.Run("repeated lines are interchangeable", func(t *testing.T) {
+		m := initialModel(metadata{}, []string{"La la la", "Hey", "La la la"})
+		m.rand = rand.New(rand.NewSource(1))
+		m.mode = modeReorder
+		m.selectSection(-1)
+		m.beginRun()
+		m.order = []int{2, 1, 0}
+
+		if m = press(m, tea.KeyEnter); m.runScore().correct != 3 {
+			t.Errorf("results = %v, want all right", m.results)
+		}
+	})
+
+	t.Run("view marks the cursor and held line", func(t *testing.T) {
+		m := newReorder(0)
+		if view := m.View(); !strings.Contains(view, "> "+lyricText(m.lines[m.order[0]])) {
+			t.Errorf("view should mark the cursor, got: %s", view)
+		}
+		m = press(m, tea.KeySpace)
+		if view := m.View(); !strings.Contains(view, "» ") {
+			t.Errorf("view should mark the held line, got: %s", view)
+		}
+	})
+}
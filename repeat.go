@@ -0,0 +1,196 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// repeatGroupRe matches the parenthesized repeat marker at the end of a
+	// header, e.g. "(repeat)", "(repeat x2)" or "(x2)"
+	repeatGroupRe = regexp.MustCompile(`(?i)\s*\((repeat)?\s*(?:[x×]\s*(\d+))?\)$`)
+
+	// repeatCountRe matches a bare repeat count at the end of a header, e.g.
+	// "x2" or "×3"
+	repeatCountRe = regexp.MustCompile(`(?i)\s+[x×]\s*(\d+)$`)
+
+	// repeatLineRe matches a "[Repeat Chorus]" line
+	repeatLineRe = regexp.MustCompile(`(?i)^\[repeat\s+([^|\]]+)\]$`)
+)
+
+// parseRepeat splits a section header or reference into the section name
+// and how many times it's sung. Repeat is true if it's explicitly marked as
+// a repeat of an earlier section.
+func parseRepeat(s string) (name string, times int, repeat bool) {
+	name, times = strings.TrimSpace(s), 1
+	if m := repeatGroupRe.FindStringSubmatchIndex(name); m != nil && m[0] > 0 && (m[2] >= 0 || m[4] >= 0) {
+		repeat = m[2] >= 0
+		if m[4] >= 0 {
+			times, _ = strconv.Atoi(name[m[4]:m[5]])
+		}
+		name = strings.TrimSpace(name[:m[0]])
+	} else if m := repeatCountRe.FindStringSubmatchIndex(name); m != nil {
+		times, _ = strconv.Atoi(name[m[2]:m[3]])
+		name = strings.TrimSpace(name[:m[0]])
+	}
+	return name, max(times, 1), repeat
+}
+
+// parseReference returns the section a line refers back to, written as
+// "@Chorus" or "[Repeat Chorus]", along with how many times it's sung
+func parseReference(line string) (name string, times int, ok bool) {
+	line = strings.TrimSpace(line)
+	if rest, found := strings.CutPrefix(line, "@"); found {
+		name, times, _ = parseRepeat(rest)
+		return name, times, name != ""
+	}
+	if m := repeatLineRe.FindStringSubmatch(line); m != nil {
+		name, times, _ = parseRepeat(m[1])
+		return name, times, true
+	}
+	return "", 0, false
+}
+
+// expandRepeats replaces references to earlier sections with a copy of
+// their lines, so a chorus written out once can be practiced everywhere
+// it's sung. References are written as a header marked "(repeat)", a line
+// such as "@Chorus" or "[Repeat Chorus]", or a header with a count such as
+// "# Chorus x2" and no lines of its own. A count also repeats a section's
+// own lines. Copies take in the sections nested under the one referred to,
+// and leave out timestamps, which belong to the first time it's sung.
+// References to no earlier section are left as they're written, so a lyric
+// such as "@home tonight" is kept and an "(repeat)" header starts a section.
+func expandRepeats(lines []string) []string {
+	var out []string
+	defined := make(map[string]int) // section name to where its header is in out
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		if name, times, ok := parseReference(line); ok {
+			start, found := defined[normalize(name)]
+			if !found {
+				out = append(out, line) // a lyric or a note in brackets
+				continue
+			}
+			out = append(out, repeatBody(definition(out, start), times)...)
+			continue
+		}
+
+		if !isComment(line) {
+			out = append(out, line)
+			continue
+		}
+
+		name, times, repeat := parseRepeat(headerText(line))
+		header := line
+		if name != headerText(line) {
+			header = strings.Repeat("#", headerLevel(line)) + " " + name
+		}
+
+		// Collect the section's own lines, up to the next header or a
+		// reference to this or an earlier section
+		end := i + 1
+		for end < len(lines) && !isComment(lines[end]) {
+			if ref, _, ok := parseReference(lines[end]); ok {
+				if _, found := defined[normalize(ref)]; found || normalize(ref) == normalize(name) {
+					break
+				}
+			}
+			end++
+		}
+		body := lines[i+1 : end]
+
+		start, found := defined[normalize(name)]
+		switch {
+		case found && (repeat || (times > 1 && len(body) == 0)):
+			copied := repeatBody(definition(out, start), times)
+			out = append(out, header)
+			out = append(out, copied[1:]...)
+			out = append(out, body...)
+		default:
+			if repeat {
+				header = line // nothing earlier to repeat
+			}
+			defined[normalize(name)] = len(out)
+			out = append(out, header)
+			for n := 0; n < times; n++ {
+				out = append(out, body...)
+			}
+		}
+		i = end - 1
+	}
+	return out
+}
+
+// definition returns the header at out[start] with its lines and the
+// sections nested under it
+func definition(out []string, start int) []string {
+	level := headerLevel(out[start])
+	end := start + 1
+	for end < len(out) && !(isComment(out[end]) && headerLevel(out[end]) <= level) {
+		end++
+	}
+	return out[start:end]
+}
+
+// repeatBody returns a section's header followed by its lines sung times
+// times, without timestamps
+func repeatBody(def []string, times int) []string {
+	out := []string{def[0]}
+	for n := 0; n < times; n++ {
+		for _, line := range def[1:] {
+			out = append(out, strings.TrimSpace(stripTimestamps(line)))
+		}
+	}
+	return out
+}
+
+// sameSections returns, for each section, the first section with the same
+// lines, ignoring timestamps, case and punctuation. A section with no lines
+// of its own is never the same as another.
+func sameSections(lines []string, sections []section) []int {
+	firstOf := make([]int, len(sections))
+	seen := make(map[string]int)
+	for s, sec := range sections {
+		firstOf[s] = s
+
+		var key []string
+		for _, line := range lines[sec.startIdx:sec.endIdx] {
+			if !isComment(line) {
+				key = append(key, normalize(stripTimestamps(line)))
+			}
+		}
+		if len(key) == 0 {
+			continue
+		}
+		if first, ok := seen[strings.Join(key, "\n")]; ok {
+			firstOf[s] = first
+		} else {
+			seen[strings.Join(key, "\n")] = s
+		}
+	}
+	return firstOf
+}
+
+// timesSung returns how many sections have the same lines as section s
+func (m model) timesSung(s int) int {
+	n := 0
+	for _, first := range m.firstOf {
+		if first == m.firstOf[s] {
+			n++
+		}
+	}
+	return n
+}
+
+// hasRepeats returns true if any section has the same lines as another
+func (m model) hasRepeats() bool {
+	for s, first := range m.firstOf {
+		if first != s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseRepeat(t *testing.T) {
+	tests := []struct {
+		in     string
+		name   string
+		times  int
+		repeat bool
+	}{
+		{"Chorus", "Chorus", 1, false},
+		{"Chorus (repeat)", "Chorus", 1, true},
+		{"Chorus (Repeat x2)", "Chorus", 2, true},
+		{"Chorus (x3)", "Chorus", 3, false},
+		{"Chorus x2", "Chorus", 2, false},
+		{"Chorus ×2", "Chorus", 2, false},
+		{"Verse 1", "Verse 1", 1, false},
+		{"Verse (Live)", "Verse (Live)", 1, false},
+		{"Xanadu", "Xanadu", 1, false},
+		{"(repeat)", "(repeat)", 1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			name, times, repeat := parseRepeat(tt.in)
+			if name != tt.name || times != tt.times || repeat != tt.repeat {
+				t.Errorf("parseRepeat(%q) = %q, %d, %v, want %q, %d, %v", tt.in, name, times, repeat, tt.name, tt.times, tt.repeat)
+			}
+		})
+	}
+}
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		line  string
+		name  string
+		times int
+		ok    bool
+	}{
+		{"@Chorus", "Chorus", 1, true},
+		{"  @Chorus x2", "Chorus", 2, true},
+		{"[Repeat Chorus]", "Chorus", 1, true},
+		{"[repeat chorus x3]", "chorus", 3, true},
+		{"@", "", 0, false},
+		{"[Chorus]", "", 0, false},
+		{"Mail me @ home", "", 0, false},
+		{"# Chorus", "", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			name, times, ok := parseReference(tt.line)
+			if ok != tt.ok || (ok && (name != tt.name || times != tt.times)) {
+				t.Errorf("parseReference(%q) = %q, %d, %v, want %q, %d, %v", tt.line, name, times, ok, tt.name, tt.times, tt.ok)
+			}
+		})
+	}
+}
+
+func TestExpandRepeats(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		want  []string
+	}{
+		{
+			name:  "no repeats",
+			lines: []string{"# Verse", "a", "# Chorus", "b"},
+			want:  []string{"# Verse", "a", "# Chorus", "b"},
+		},
+		{
+			name:  "repeat header",
+			lines: []string{"# Chorus", "b", "c", "# Verse 2", "d", "# Chorus (repeat)"},
+			want:  []string{"# Chorus", "b", "c", "# Verse 2", "d", "# Chorus", "b", "c"},
+		},
+		{
+			name:  "at reference",
+			lines: []string{"# Chorus", "b", "# Verse 2", "d", "@Chorus"},
+			want:  []string{"# Chorus", "b", "# Verse 2", "d", "# Chorus", "b"},
+		},
+		{
+			name:  "bracket reference with a count",
+			lines: []string{"# Chorus", "b", "[Repeat Chorus x2]"},
+			want:  []string{"# Chorus", "b", "# Chorus", "b", "b"},
+		},
+		{
+			name:  "count on a header with lines repeats them",
+			lines: []string{"# Chorus x2", "b", "c"},
+			want:  []string{"# Chorus", "b", "c", "b", "c"},
+		},
+		{
+			name:  "count on an empty header repeats the earlier section",
+			lines: []string{"# Chorus", "b", "# Verse", "d", "# Chorus x2"},
+			want:  []string{"# Chorus", "b", "# Verse", "d", "# Chorus", "b", "b"},
+		},
+		{
+			name:  "names ignore case and punctuation",
+			lines: []string{"# Pre-Chorus", "b", "@prechorus"},
+			want:  []string{"# Pre-Chorus", "b", "# Pre-Chorus", "b"},
+		},
+		{
+			name:  "nested header keeps its level",
+			lines: []string{"## Refrain", "b", "## Refrain (repeat)"},
+			want:  []string{"## Refrain", "b", "## Refrain", "b"},
+		},
+		{
+			name:  "copies take in nested sections",
+			lines: []string{"# Chorus", "## Call", "b", "## Response", "c", "# Verse", "d", "@Chorus"},
+			want:  []string{"# Chorus", "## Call", "b", "## Response", "c", "# Verse", "d", "# Chorus", "## Call", "b", "## Response", "c"},
+		},
+		{
+			name:  "copies drop timestamps",
+			lines: []string{"# Chorus", "[00:12.00] b", "@Chorus"},
+			want:  []string{"# Chorus", "[00:12.00] b", "# Chorus", "b"},
+		},
+		{
+			name:  "bracketed note without a section is kept",
+			lines: []string{"# Verse", "[Repeat until fade]"},
+			want:  []string{"# Verse", "[Repeat until fade]"},
+		},
+		{
+			name:  "at line without an earlier section is a lyric",
+			lines: []string{"# Verse", "a", "@home tonight", "b"},
+			want:  []string{"# Verse", "a", "@home tonight", "b"},
+		},
+		{
+			name:  "lyric at line stays in a repeated section",
+			lines: []string{"# Chorus x2", "@home tonight", "b"},
+			want:  []string{"# Chorus", "@home tonight", "b", "@home tonight", "b"},
+		},
+		{
+			name:  "repeat header without an earlier section is a plain header",
+			lines: []string{"# Verse", "a", "# Outro (Repeat)", "b"},
+			want:  []string{"# Verse", "a", "# Outro (Repeat)", "b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := expandRepeats(tt.lines); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expandRepeats(%q) = %q, want %q", tt.lines, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSameSections(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		want  []int
+	}{
+		{"no repeats", []string{"# Verse", "a", "# Chorus", "b"}, []int{0, 1}},
+		{"chorus sung three times", []string{"# Chorus", "b", "c", "# Verse", "d", "# Chorus", "b", "c", "# Chorus", "b", "c"}, []int{0, 1, 0, 0}},
+		{"different names, same lines", []string{"# Chorus", "b", "# Outro", "b"}, []int{0, 0}},
+		{"case, punctuation and timestamps ignored", []string{"# Chorus", "[00:10.00] Oh, yeah", "# Chorus", "oh yeah!"}, []int{0, 0}},
+		{"one line different", []string{"# Chorus", "b", "c", "# Chorus", "b", "e"}, []int{0, 1}},
+		{"empty sections are not repeats", []string{"# Act 1", "## Scene", "a", "# Act 2", "## Scene", "b"}, []int{0, 1, 2, 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sameSections(tt.lines, parseSections(tt.lines)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("sameSections = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// plural returns n followed by noun, with an "s" added unless n is 1
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+	if strings.HasSuffix(noun, "y") {
+		noun = strings.TrimSuffix(noun, "y") + "ie"
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// missed returns the number of lyric lines of the run that were wrong
+func (m model) missed() int {
+	n := 0
+	for i, line := range m.lines {
+		if !isComment(line) && !m.results[i] {
+			n++
+		}
+	}
+	return n
+}
+
+// canRetryMissed returns true if the result screen offers to practice just
+// the missed lines. Sing-along follows the song from the start, so it
+// can't skip lines.
+func (m model) canRetryMissed() bool {
+	return m.missed() > 0 && m.mode != modeSingAlong
+}
+
+// selectMissed narrows the lines being practiced down to the ones that
+// were wrong, keeping the header of each of their sections for context
+func (m *model) selectMissed() {
+	var lines []string
+	var indices []int
+	header := -1 // header of the current section, until a missed line needs it
+	for i, line := range m.lines {
+		if isComment(line) {
+			header = i
+			continue
+		}
+		if m.results[i] {
+			continue
+		}
+		if header >= 0 {
+			lines = append(lines, m.lines[header])
+			indices = append(indices, m.lineIndex(header))
+			header = -1
+		}
+		lines = append(lines, line)
+		indices = append(indices, m.lineIndex(i))
+	}
+
+	m.lines = lines
+	m.lineIndices = indices
+	m.retries++
+	m.resetRun()
+}
+
+// resetAttempts starts counting attempts over for a full run
+func (m *model) resetAttempts() {
+	m.attempts = make([]int, len(m.allLines))
+	m.retries = 0
+}
+
+// countAttempts adds the run that just completed to the attempts of each
+// of its lines
+func (m *model) countAttempts() {
+	for i, line := range m.lines {
+		if !isComment(line) {
+			m.attempts[m.lineIndex(i)]++
+		}
+	}
+}
+
+// writeAttempts writes how many attempts each line took once every line
+// has passed after retrying missed lines
+func (m model) writeAttempts(b *strings.Builder) {
+	if m.retries == 0 || m.missed() > 0 {
+		return
+	}
+
+	b.WriteString("\n")
+	b.WriteString(boldStyle.Render(fmt.Sprintf("Every line passed after %s:", plural(m.retries, "retry"))))
+	b.WriteString("\n")
+	for i, n := range m.attempts {
+		if n == 0 {
+			continue
+		}
+		style := greenStyle
+		if n > 1 {
+			style = redStyle
+		}
+		b.WriteString(fmt.Sprintf("  %s %s\n", style.Render(plural(n, "attempt")), lyricText(m.allLines[i])))
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestRetryMissed(t *testing.T) {
+	lines := []string{
+		"# Verse",
+		"Line one",
+		"Line two",
+		"# Chorus",
+		"Line three",
+		"# Bridge",
+		"Line four",
+	}
+
+	// run types each line of the run, correctly if it's in right
+	run := func(m model, right ...string) model {
+		for m.state == stateTyping {
+			line := m.lines[m.currentLine]
+			m.input.SetValue("wrong")
+			for _, r := range right {
+				if r == line {
+					m.input.SetValue(line)
+				}
+			}
+			newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+			m = newModel.(model)
+		}
+		return m
+	}
+
+	newRun := func() model {
+		m := initialModel(metadata{}, lines)
+		m.selectSection(-1)
+		m.beginRun()
+		return m
+	}
+
+	t.Run("r practices just the missed lines with their headers", func(t *testing.T) {
+		m := run(newRun(), "Line one", "Line three")
+		if view := m.View(); !strings.Contains(view, "Press r to retry just the 2 missed lines.") {
+			t.Errorf("view should offer to retry missed lines, got: %s", view)
+		}
+
+		m = press(m, 'r')
+		want := []string{"# Verse", "Line two", "# Bridge", "Line four"}
+		if strings.Join(m.lines, "|") != strings.Join(want, "|") {
+			t.Errorf("lines = %q, want %q", m.lines, want)
+		}
+		if m.lineIndices[1] != 2 || m.lineIndices[3] != 6 {
+			t.Errorf("lineIndices = %v, want to map back to allLines", m.lineIndices)
+		}
+		if m.state != stateTyping || m.currentLine != 1 {
+			t.Errorf("state = %v, currentLine = %d, want typing the first missed line", m.state, m.currentLine)
+		}
+	})
+
+	t.Run("loops until every line passes", func(t *testing.T) {
+		m := run(newRun(), "Line one", "Line three")
+		m = run(press(m, 'r'), "Line two")
+		if m = press(m, 'r'); strings.Join(m.lines, "|") != "# Bridge|Line four" {
+			t.Fatalf("lines = %q, want just the bridge", m.lines)
+		}
+
+		m = run(m)
+		m = run(press(m, 'r'), "Line four")
+
+		view := m.View()
+		if !strings.Contains(view, "Every line passed after 3 retries:") {
+			t.Fatalf("view should show attempts summary, got: %s", view)
+		}
+		for _, want := range []string{"1 attempt Line one", "2 attempts Line two", "1 attempt Line three", "4 attempts Line four"} {
+			if !strings.Contains(view, want) {
+				t.Errorf("view should contain %q, got: %s", want, view)
+			}
+		}
+		if strings.Contains(view, "Press r") {
+			t.Errorf("view should not offer a retry when nothing was missed, got: %s", view)
+		}
+	})
+
+	t.Run("r does nothing when nothing was missed", func(t *testing.T) {
+		m := run(newRun(), "Line one", "Line two", "Line three", "Line four")
+		if m = press(m, 'r'); m.state != stateResult {
+			t.Errorf("state = %v, want stateResult", m.state)
+		}
+		if strings.Contains(m.View(), "Every line passed") {
+			t.Error("summary should only show after retrying")
+		}
+	})
+
+	t.Run("y starts over with every line", func(t *testing.T) {
+		m := run(newRun(), "Line one")
+		m = run(press(m, 'r'))
+		m = press(m, 'y')
+
+		if len(m.lines) != len(lines) {
+			t.Errorf("lines = %q, want every line", m.lines)
+		}
+		if m.retries != 0 || m.attempts[1] != 0 {
+			t.Errorf("retries = %d, attempts = %v, want counts reset", m.retries, m.attempts)
+		}
+	})
+}
+
+func TestPlural(t *testing.T) {
+	for _, tt := range []struct {
+		n    int
+		noun string
+		want string
+	}{
+		{1, "attempt", "1 attempt"},
+		{2, "attempt", "2 attempts"},
+		{0, "line", "0 lines"},
+		{1, "retry", "1 retry"},
+		{3, "retry", "3 retries"},
+	} {
+		if got := plural(tt.n, tt.noun); got != tt.want {
+			t.Errorf("plural(%d, %q) = %q, want %q", tt.n, tt.noun, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,420 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// reviewFilename is the name of the review schedule file inside the data dir
+const reviewFilename = "review.json"
+
+// reviewExtensions lists the file extensions picked up when scanning a
+// library directory for lyrics files
+var reviewExtensions = map[string]bool{
+	".txt": true,
+	".lrc": true,
+}
+
+// SM-2 scheduling constants
+const (
+	defaultEase = 2.5
+	minEase     = 1.3
+)
+
+// card is a single reviewable section of a lyrics file, scheduled with SM-2
+type card struct {
+	File     string    `json:"file"`
+	Section  string    `json:"section"`
+	Ease     float64   `json:"ease"`
+	Interval int       `json:"interval"` // days until the next review
+	Reps     int       `json:"reps"`     // consecutive successful reviews
+	Due      time.Time `json:"due"`
+	Reviewed time.Time `json:"reviewed,omitempty"`
+}
+
+// newCard returns an unreviewed card which is due immediately
+func newCard(file, section string) *card {
+	return &card{File: file, Section: section, Ease: defaultEase}
+}
+
+// isDue returns true if the card should be reviewed at now
+func (c *card) isDue(now time.Time) bool {
+	return !c.Due.After(now)
+}
+
+// schedule updates the card after a review graded from 0 (complete
+// blackout) to 5 (perfect recall) using the SM-2 algorithm.
+func (c *card) schedule(grade int, now time.Time) {
+	if grade < 3 {
+		c.Reps = 0
+		c.Interval = 1
+	} else {
+		c.Reps++
+		switch c.Reps {
+		case 1:
+			c.Interval = 1
+		case 2:
+			c.Interval = 6
+		default:
+			c.Interval = int(math.Round(float64(c.Interval) * c.Ease))
+		}
+	}
+
+	q := float64(5 - grade)
+	c.Ease += 0.1 - q*(0.08+q*0.02)
+	if c.Ease < minEase {
+		c.Ease = minEase
+	}
+
+	c.Reviewed = now
+	c.Due = now.AddDate(0, 0, c.Interval)
+}
+
+// gradeLines converts the results of a card's lines into an SM-2 grade.
+// Lines recalled without hints score highest, hints lower the grade, and
+// any missed line fails the card.
+func gradeLines(results []bool, hints []int) int {
+	if len(results) == 0 {
+		return 5
+	}
+
+	correct, maxHint := 0, 0
+	for i, ok := range results {
+		if ok {
+			correct++
+		}
+		if hints[i] > maxHint {
+			maxHint = hints[i]
+		}
+	}
+
+	switch {
+	case correct == len(results):
+		return 5 - maxHint // 5 without hints, 4 with a word hint, 3 with the full line
+	case correct*2 >= len(results):
+		return 2
+	case correct > 0:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// reviewStore holds the schedule for every card, keyed by file and section
+type reviewStore struct {
+	cards map[[2]string]*card
+}
+
+// loadReviewStore reads the schedule from path. A missing file is empty.
+func loadReviewStore(path string) (*reviewStore, error) {
+	s := &reviewStore{cards: make(map[[2]string]*card)}
+
+	buf, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var cards []*card
+	if err := json.Unmarshal(buf, &cards); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for _, c := range cards {
+		s.cards[[2]string{c.File, c.Section}] = c
+	}
+	return s, nil
+}
+
+// card returns the card for a section, creating a new one if needed
+func (s *reviewStore) card(file, section string) *card {
+	key := [2]string{file, section}
+	c := s.cards[key]
+	if c == nil {
+		c = newCard(file, section)
+		s.cards[key] = c
+	}
+	return c
+}
+
+// save writes the schedule to path, sorted so the file diffs cleanly
+func (s *reviewStore) save(path string) error {
+	cards := make([]*card, 0, len(s.cards))
+	for _, c := range s.cards {
+		cards = append(cards, c)
+	}
+	sort.Slice(cards, func(i, j int) bool {
+		if cards[i].File != cards[j].File {
+			return cards[i].File < cards[j].File
+		}
+		return cards[i].Section < cards[j].Section
+	})
+
+	buf, err := json.MarshalIndent(cards, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(buf, '\n'), 0o644)
+}
+
+// reviewItem maps a due card to its lines within the combined review set
+type reviewItem struct {
+	card     *card
+	name     string   // header shown for the card, e.g. "Song - Chorus"
+	rules    *ruleSet // matching rules of the card's file, nil for the defaults
+	startIdx int      // inclusive, index into allLines
+	endIdx   int      // exclusive
+}
+
+// reviewSession tracks the cards being reviewed in a single run
+type reviewSession struct {
+	path   string // schedule file, empty to disable saving
+	store  *reviewStore
+	items  []reviewItem
+	graded bool // cards are only graded on the first pass through
+}
+
+// reviewSavedMsg is sent after the review schedule has been written
+type reviewSavedMsg struct {
+	err error
+}
+
+// findLyricsFiles returns all lyrics files under dir, skipping hidden
+// files and directories.
+func findLyricsFiles(dir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != dir && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Type().IsRegular() && reviewExtensions[strings.ToLower(filepath.Ext(path))] {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// buildReview collects the sections of files that are due at now and
+// returns them as a single set of lines, one header per card, with the
+// most overdue cards first. Files that can't be read are left out and
+// returned as skipped, so one broken file doesn't stop the review.
+func buildReview(store *reviewStore, files []string, now time.Time) (lines []string, items []reviewItem, skipped []error) {
+	type dueSection struct {
+		item  reviewItem
+		lines []string
+	}
+	var due []dueSection
+
+	for _, file := range files {
+		meta, fileLines, err := readFile(file)
+		if err != nil {
+			skipped = append(skipped, fmt.Errorf("%s: %w", file, err))
+			continue
+		}
+
+		title := meta.Title
+		if title == "" {
+			title = strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+		}
+
+		seen := make(map[string]bool)
+		sections := parseSections(fileLines)
+		for s, sec := range sections {
+			// Repeated sections such as a chorus are reviewed once. Nested
+			// sections go by their full path, since "Scene 1" may be in
+			// every act.
+			path := sectionPath(sections, s)
+			if seen[path] {
+				continue
+			}
+			seen[path] = true
+
+			c := store.card(file, path)
+			if !c.isDue(now) {
+				continue
+			}
+
+			body := fileLines[sec.startIdx:sec.endIdx]
+			if len(body) > 0 && isComment(body[0]) {
+				body = body[1:]
+			}
+			if len(body) == 0 {
+				continue
+			}
+
+			name := title + " - " + path
+			due = append(due, dueSection{
+				item:  reviewItem{card: c, name: name, rules: meta.rules},
+				lines: append([]string{"# " + name}, body...),
+			})
+		}
+	}
+
+	sort.SliceStable(due, func(i, j int) bool {
+		return due[i].item.card.Due.Before(due[j].item.card.Due)
+	})
+
+	for _, d := range due {
+		d.item.startIdx = len(lines)
+		lines = append(lines, d.lines...)
+		d.item.endIdx = len(lines)
+		items = append(items, d.item)
+	}
+	return lines, items, skipped
+}
+
+// rulesFor returns the matching rules for line i of the run. In a review
+// each line is matched by the rules of the file its card came from.
+func (m model) rulesFor(i int) *ruleSet {
+	if m.review != nil {
+		idx := m.lineIndex(i)
+		for _, item := range m.review.items {
+			if idx >= item.startIdx && idx < item.endIdx && item.rules != nil {
+				return item.rules
+			}
+		}
+	}
+	return m.rules
+}
+
+// nextDue returns the earliest due date among all cards in the store
+func (s *reviewStore) nextDue() (time.Time, bool) {
+	var next time.Time
+	for _, c := range s.cards {
+		if next.IsZero() || c.Due.Before(next) {
+			next = c.Due
+		}
+	}
+	return next, !next.IsZero()
+}
+
+// gradeReview schedules every card whose lines were part of the run that
+// just completed. It does nothing outside of a review or on a retry, or
+// after a quiz or reorder drill since picking lines isn't recalling them.
+func (m *model) gradeReview(now time.Time) {
+	if m.review == nil || m.review.graded || m.mode.picksLines() {
+		return
+	}
+	m.review.graded = true
+
+	// Map each practiced line back to its index in allLines
+	pos := make(map[int]int)
+	for i := range m.lines {
+		pos[m.lineIndex(i)] = i
+	}
+
+	for _, item := range m.review.items {
+		var results []bool
+		var hints []int
+		for idx := item.startIdx; idx < item.endIdx; idx++ {
+			i, ok := pos[idx]
+			if !ok || isComment(m.lines[i]) {
+				continue
+			}
+			results = append(results, m.results[i])
+			hints = append(hints, m.hintsUsed[i])
+		}
+		if len(results) > 0 {
+			item.card.schedule(gradeLines(results, hints), now)
+		}
+	}
+}
+
+// saveReview returns a command that writes the review schedule, or nil if
+// this run is not a review.
+func (m model) saveReview() tea.Cmd {
+	if m.review == nil || m.review.path == "" {
+		return nil
+	}
+	path, store := m.review.path, m.review.store
+	return func() tea.Msg {
+		return reviewSavedMsg{err: store.save(path)}
+	}
+}
+
+// writeReviewSummary writes when each reviewed card is due next
+func (m model) writeReviewSummary(b *strings.Builder) {
+	if m.review == nil || !m.review.graded {
+		return
+	}
+	b.WriteString("\n")
+	b.WriteString(boldStyle.Render("Next review:"))
+	b.WriteString("\n")
+	for _, item := range m.review.items {
+		if item.card.Reviewed.IsZero() {
+			continue
+		}
+		days := "tomorrow"
+		if item.card.Interval != 1 {
+			days = fmt.Sprintf("in %d days", item.card.Interval)
+		}
+		b.WriteString(fmt.Sprintf("  %s %s\n", item.name, dimStyle.Render(days)))
+	}
+}
+
+// runReview implements the "review" subcommand
+func runReview(w io.Writer, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: recite review <dir>")
+	}
+
+	dir, err := filepath.Abs(args[0])
+	if err != nil {
+		return err
+	}
+	files, err := findLyricsFiles(dir)
+	if err != nil {
+		return err
+	}
+
+	ddir, err := dataDir()
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(ddir, reviewFilename)
+	store, err := loadReviewStore(path)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	lines, items, skipped := buildReview(store, files, now)
+	for _, err := range skipped {
+		fmt.Fprintf(w, "Skipping %v\n", err)
+	}
+	if len(items) == 0 {
+		if next, ok := store.nextDue(); ok {
+			fmt.Fprintf(w, "Nothing due for review. Next review: %s\n", next.Local().Format("2006-01-02 15:04"))
+		} else if len(skipped) == 0 {
+			fmt.Fprintln(w, "No lyrics files found.")
+		}
+		return nil
+	}
+
+	m := initialModel(metadata{Title: "Review"}, lines)
+	m.review = &reviewSession{path: path, store: store, items: items}
+
+	_, err = tea.NewProgram(m).Run()
+	return err
+}
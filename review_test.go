@@ -0,0 +1,303 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCardSchedule(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("successful reviews grow the interval", func(t *testing.T) {
+		c := newCard("song.txt", "Chorus")
+
+		c.schedule(5, now)
+		if c.Interval != 1 {
+			t.Errorf("first interval = %d, want 1", c.Interval)
+		}
+		c.schedule(5, now)
+		if c.Interval != 6 {
+			t.Errorf("second interval = %d, want 6", c.Interval)
+		}
+		c.schedule(5, now)
+		if c.Interval <= 6 {
+			t.Errorf("third interval = %d, want > 6", c.Interval)
+		}
+		if !c.Due.Equal(now.AddDate(0, 0, c.Interval)) {
+			t.Errorf("Due = %v, want %v", c.Due, now.AddDate(0, 0, c.Interval))
+		}
+	})
+
+	t.Run("failed review resets repetitions", func(t *testing.T) {
+		c := newCard("song.txt", "Chorus")
+		c.schedule(5, now)
+		c.schedule(5, now)
+
+		c.schedule(1, now)
+		if c.Reps != 0 || c.Interval != 1 {
+			t.Errorf("Reps = %d, Interval = %d, want 0, 1", c.Reps, c.Interval)
+		}
+		if c.Ease >= defaultEase {
+			t.Errorf("Ease = %v, should drop below %v", c.Ease, defaultEase)
+		}
+	})
+
+	t.Run("ease never drops below minimum", func(t *testing.T) {
+		c := newCard("song.txt", "Chorus")
+		for i := 0; i < 20; i++ {
+			c.schedule(0, now)
+		}
+		if c.Ease != minEase {
+			t.Errorf("Ease = %v, want %v", c.Ease, minEase)
+		}
+	})
+}
+
+func TestGradeLines(t *testing.T) {
+	tests := []struct {
+		name    string
+		results []bool
+		hints   []int
+		want    int
+	}{
+		{"perfect", []bool{true, true}, []int{0, 0}, 5},
+		{"word hint", []bool{true, true}, []int{1, 0}, 4},
+		{"full line hint", []bool{true, true}, []int{1, 2}, 3},
+		{"half correct", []bool{true, false}, []int{0, 0}, 2},
+		{"mostly wrong", []bool{true, false, false}, []int{0, 0, 0}, 1},
+		{"all wrong", []bool{false, false}, []int{0, 0}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := gradeLines(tt.results, tt.hints); got != tt.want {
+				t.Errorf("gradeLines(%v, %v) = %d, want %d", tt.results, tt.hints, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReviewStore(t *testing.T) {
+	t.Run("saves and loads cards", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "recite", reviewFilename)
+		now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+		s, err := loadReviewStore(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.card("song.txt", "Chorus").schedule(5, now)
+		if err := s.save(path); err != nil {
+			t.Fatal(err)
+		}
+
+		other, err := loadReviewStore(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c := other.card("song.txt", "Chorus")
+		if c.Reps != 1 || !c.Due.Equal(now.AddDate(0, 0, 1)) {
+			t.Errorf("unexpected card after reload: %+v", c)
+		}
+	})
+}
+
+// writeLibrary creates lyrics files in a temp dir and returns its path
+func writeLibrary(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestFindLyricsFiles(t *testing.T) {
+	dir := writeLibrary(t, map[string]string{
+		"a.txt":         "Line",
+		"poems/b.txt":   "Line",
+		"notes.pdf":     "binary",
+		".hidden/c.txt": "Line",
+	})
+
+	files, err := findLyricsFiles(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("files = %v, want a.txt and poems/b.txt", files)
+	}
+}
+
+func TestBuildReview(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	dir := writeLibrary(t, map[string]string{
+		"song.txt": "---\ntitle: Song\n---\n# Verse\nVerse line\n# Chorus\nChorus line\n# Chorus\nChorus line\n",
+	})
+	file := filepath.Join(dir, "song.txt")
+
+	t.Run("new sections are due once each", func(t *testing.T) {
+		store := &reviewStore{cards: make(map[[2]string]*card)}
+
+		lines, items, skipped := buildReview(store, []string{file}, now)
+		if len(skipped) > 0 {
+			t.Fatal(skipped)
+		}
+		if len(items) != 2 {
+			t.Fatalf("len(items) = %d, want 2 (repeated chorus reviewed once)", len(items))
+		}
+		want := []string{"# Song - Verse", "Verse line", "# Song - Chorus", "Chorus line"}
+		if strings.Join(lines, "|") != strings.Join(want, "|") {
+			t.Errorf("lines = %q, want %q", lines, want)
+		}
+	})
+
+	t.Run("only due sections are included", func(t *testing.T) {
+		store := &reviewStore{cards: make(map[[2]string]*card)}
+		store.card(file, "Verse").schedule(5, now)
+
+		lines, items, skipped := buildReview(store, []string{file}, now)
+		if len(skipped) > 0 {
+			t.Fatal(skipped)
+		}
+		if len(items) != 1 || items[0].card.Section != "Chorus" {
+			t.Fatalf("items = %+v, want only Chorus", items)
+		}
+		if items[0].startIdx != 0 || items[0].endIdx != 2 || len(lines) != 2 {
+			t.Errorf("unexpected range [%d, %d) for lines %q", items[0].startIdx, items[0].endIdx, lines)
+		}
+	})
+
+	t.Run("completing a review schedules each card", func(t *testing.T) {
+		store := &reviewStore{cards: make(map[[2]string]*card)}
+		lines, items, skipped := buildReview(store, []string{file}, now)
+		if len(skipped) > 0 {
+			t.Fatal(skipped)
+		}
+
+		m := initialModel(metadata{}, lines)
+		m.review = &reviewSession{store: store, items: items}
+		m.results = []bool{true, true, true, false}
+
+		m.gradeReview(now)
+		if c := store.card(file, "Verse"); c.Reps != 1 {
+			t.Errorf("Verse Reps = %d, want 1", c.Reps)
+		}
+		if c := store.card(file, "Chorus"); c.Reps != 0 || c.Reviewed.IsZero() {
+			t.Errorf("Chorus should be failed and reviewed: %+v", c)
+		}
+
+		// A retry of the same session does not grade again
+		m.gradeReview(now)
+		if c := store.card(file, "Verse"); c.Reps != 1 {
+			t.Errorf("Verse Reps = %d after retry, want 1", c.Reps)
+		}
+
+		m.state = stateResult
+		m.currentLine = len(m.lines)
+		if view := m.View(); !strings.Contains(view, "Next review:") {
+			t.Errorf("result view should show next review, got: %s", view)
+		}
+	})
+}
+
+func TestReviewTimestampedFiles(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	dir := writeLibrary(t, map[string]string{
+		"a.lrc": "[ti:First]\n[00:05.00]Twinkle twinkle little star\n[00:10.00]How I wonder what you are\n",
+		"b.lrc": "[ti:Second]\n[00:05.00]Up above the world so high\n[00:10.00]Like a diamond in the sky\n",
+	})
+	files, err := findLyricsFiles(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := &reviewStore{cards: make(map[[2]string]*card)}
+	lines, items, skipped := buildReview(store, files, now)
+	if len(skipped) > 0 {
+		t.Fatal(skipped)
+	}
+	if len(items) != 2 {
+		t.Fatalf("len(items) = %d, want a card for each song", len(items))
+	}
+
+	m := initialModel(metadata{}, lines)
+	m.review = &reviewSession{store: store, items: items}
+	for _, md := range m.availableModes() {
+		if md == modeSingAlong {
+			t.Fatal("sing-along should not be offered in a review")
+		}
+	}
+
+	for i := range m.results {
+		m.results[i] = true
+	}
+	m.gradeReview(now)
+	for _, item := range items {
+		if item.card.Reps != 1 || item.card.Interval != 1 {
+			t.Errorf("%s: Reps = %d, Interval = %d, want a passed first review", item.name, item.card.Reps, item.card.Interval)
+		}
+	}
+}
+
+func TestReviewMatchRules(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	dir := writeLibrary(t, map[string]string{
+		"a.txt": "---\ntitle: Strict\nmatch:\n  strictness: strict\n---\nHello, World\n",
+		"b.txt": "---\ntitle: Normal\n---\nHello, World\n",
+	})
+	files, err := findLyricsFiles(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines, items, skipped := buildReview(&reviewStore{cards: make(map[[2]string]*card)}, files, now)
+	if len(skipped) > 0 {
+		t.Fatal(skipped)
+	}
+
+	m := initialModel(metadata{Title: "Review"}, lines)
+	m.review = &reviewSession{items: items}
+	m.mode = modeMemory
+	m.selectSection(-1)
+	m.beginRun()
+	for m.state == stateTyping {
+		m.input.SetValue("hello world")
+		m.submitLine()
+	}
+
+	for _, item := range items {
+		line := item.startIdx + 1
+		want := !strings.Contains(item.name, "Strict")
+		if m.results[line] != want {
+			t.Errorf("%s: result = %v, want %v", item.name, m.results[line], want)
+		}
+	}
+}
+
+func TestReviewSkipsBrokenFiles(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	dir := writeLibrary(t, map[string]string{
+		"good.txt":   "# Verse\nVerse line\n",
+		"broken.txt": "---\nrecite:\n  section: Bridge\n---\n# Verse\nVerse line\n",
+	})
+	files, err := findLyricsFiles(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, items, skipped := buildReview(&reviewStore{cards: make(map[[2]string]*card)}, files, now)
+	if len(items) != 1 || !strings.HasSuffix(items[0].card.File, "good.txt") {
+		t.Errorf("items = %+v, want just the good file", items)
+	}
+	if len(skipped) != 1 || !strings.Contains(skipped[0].Error(), "broken.txt: invalid YAML front matter: line 3") {
+		t.Errorf("skipped = %v, want the broken file", skipped)
+	}
+}
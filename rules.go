@@ -0,0 +1,316 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// typoMinLength is the shortest word the typo rule forgives a typo in, so
+// short words like "sin" and "son" are still told apart
+const typoMinLength = 4
+
+// wordRule is a leniency rule that reports whether a normalized input word
+// is an accepted form of a normalized expected word
+type wordRule struct {
+	name  string
+	match func(input, expected string) bool
+}
+
+// builtinRules lists the word rules that can be enabled by name
+var builtinRules = []wordRule{
+	{name: "g-dropping", match: gDropped},
+	{name: "typos", match: typo},
+	{name: "accents", match: accentsFolded},
+}
+
+// builtinVariants lists named groups of words and phrases that are
+// accepted in place of each other
+var builtinVariants = map[string][][]string{
+	"contractions": {
+		{"going to", "gonna"},
+		{"want to", "wanna"},
+		{"got to", "gotta"},
+		{"got you", "gotcha"},
+		{"give me", "gimme"},
+		{"let me", "lemme"},
+		{"kind of", "kinda"},
+		{"sort of", "sorta"},
+		{"out of", "outta"},
+		{"don't know", "dunno"},
+		{"because", "'cause", "cuz"},
+		{"until", "'til", "till"},
+		{"them", "'em"},
+		{"about", "'bout"},
+		{"around", "'round"},
+		{"and", "'n'", "n"},
+		{"okay", "ok"},
+		{"all right", "alright"},
+	},
+	"numbers": {
+		{"0", "zero"}, {"1", "one"}, {"2", "two"}, {"3", "three"}, {"4", "four"},
+		{"5", "five"}, {"6", "six"}, {"7", "seven"}, {"8", "eight"}, {"9", "nine"},
+		{"10", "ten"}, {"11", "eleven"}, {"12", "twelve"}, {"13", "thirteen"},
+		{"14", "fourteen"}, {"15", "fifteen"}, {"16", "sixteen"}, {"17", "seventeen"},
+		{"18", "eighteen"}, {"19", "nineteen"}, {"20", "twenty"}, {"100", "hundred", "a hundred", "one hundred"},
+		{"1st", "first"}, {"2nd", "second"}, {"3rd", "third"},
+	},
+	"spellings": {
+		{"color", "colour"}, {"colors", "colours"}, {"favorite", "favourite"},
+		{"honor", "honour"}, {"neighbor", "neighbour"}, {"harbor", "harbour"},
+		{"humor", "humour"}, {"labor", "labour"}, {"rumor", "rumour"},
+		{"center", "centre"}, {"theater", "theatre"}, {"meter", "metre"},
+		{"gray", "grey"}, {"mom", "mum"}, {"realize", "realise"},
+		{"apologize", "apologise"}, {"traveled", "travelled"}, {"traveling", "travelling"},
+		{"jewelry", "jewellery"}, {"catalog", "catalogue"}, {"dialog", "dialogue"},
+	},
+}
+
+// defaultRuleNames lists the rules enabled unless a file says otherwise.
+// Typo tolerance is opt-in since it can hide genuinely wrong words, and
+// accents are only folded by default for English, see language.foldsAccents.
+var defaultRuleNames = []string{"g-dropping", "contractions", "numbers", "spellings", "accents"}
+
+// defaultRules is the rule set used when a file doesn't configure matching
+var defaultRules = mustRuleSet(newRuleSet(defaultRuleNames, nil, language{}))
+
+// matchConfig is the "match" block of the YAML front matter
+type matchConfig struct {
+	Enable   []string   `yaml:"enable"`   // rules to add to the defaults
+	Disable  []string   `yaml:"disable"`  // default rules to turn off
+	Variants [][]string `yaml:"variants"` // extra groups of accepted variants
+
+	// Strictness is "strict", "normal" or "lenient"
+	Strictness string `yaml:"strictness"`
+}
+
+// ruleSet decides which words and phrases are accepted in place of the
+// expected ones when comparing lines
+type ruleSet struct {
+	rules     []wordRule
+	variants  map[string]int // normalized phrase to variant group
+	groups    int            // number of variant groups allocated
+	maxPhrase int            // number of words in the longest variant
+	lang      language       // language the lyrics are written in
+
+	strictness strictness // how closely lines must match
+}
+
+// newRuleSet builds a rule set from rule and variant group names plus any
+// extra variant groups, for lyrics written in lang.
+func newRuleSet(names []string, variants [][]string, lang language) (*ruleSet, error) {
+	rs := &ruleSet{variants: make(map[string]int), maxPhrase: 1, lang: lang}
+
+	for _, name := range names {
+		if group, ok := builtinVariants[name]; ok {
+			rs.addVariants(group)
+			continue
+		}
+
+		var found bool
+		for _, rule := range builtinRules {
+			if rule.name == name {
+				rs.rules = append(rs.rules, rule)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown match rule %q", name)
+		}
+	}
+
+	rs.addVariants(variants)
+	return rs, nil
+}
+
+func mustRuleSet(rs *ruleSet, err error) *ruleSet {
+	if err != nil {
+		panic(err)
+	}
+	return rs
+}
+
+// ruleSet builds the rule set described by the front matter for lyrics in
+// the given language. A language that isn't a language tag, such as
+// "English", gets the default matching.
+func (c matchConfig) ruleSet(tag string) (*ruleSet, error) {
+	lang, err := parseLanguage(tag)
+	if err != nil {
+		lang = language{}
+	}
+	level, err := parseStrictness(c.Strictness)
+	if err != nil {
+		return nil, err
+	}
+	if lang.code == "" && level == strictnessNormal && len(c.Enable) == 0 && len(c.Disable) == 0 && len(c.Variants) == 0 {
+		return defaultRules, nil
+	}
+
+	disabled := make(map[string]bool)
+	for _, name := range c.Disable {
+		if !isRuleName(name) {
+			return nil, fmt.Errorf("unknown match rule %q", name)
+		}
+		disabled[name] = true
+	}
+
+	var defaults []string
+	for _, name := range defaultRuleNames {
+		if name != "accents" || lang.foldsAccents() {
+			defaults = append(defaults, name)
+		}
+	}
+	if level == strictnessLenient {
+		defaults = append(defaults, "typos")
+	}
+
+	var names []string
+	for _, name := range append(defaults, c.Enable...) {
+		if !disabled[name] {
+			names = append(names, name)
+		}
+	}
+	rs, err := newRuleSet(names, c.Variants, lang)
+	if err != nil {
+		return nil, err
+	}
+	rs.strictness = level
+	return rs, nil
+}
+
+// isRuleName returns true if name is a built-in rule or variant group
+func isRuleName(name string) bool {
+	if _, ok := builtinVariants[name]; ok {
+		return true
+	}
+	for _, rule := range builtinRules {
+		if rule.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// addVariants adds groups of interchangeable phrases. A phrase that is
+// already in a group joins the two groups together.
+func (rs *ruleSet) addVariants(groups [][]string) {
+	for _, group := range groups {
+		rs.groups++
+		id := rs.groups
+		for _, phrase := range group {
+			if existing, ok := rs.variants[rs.normalizePhrase(phrase)]; ok {
+				id = existing
+				break
+			}
+		}
+		for _, phrase := range group {
+			key := rs.normalizePhrase(phrase)
+			if key == "" {
+				continue
+			}
+			if existing, ok := rs.variants[key]; ok && existing != id {
+				rs.mergeVariants(existing, id)
+			}
+			rs.variants[key] = id
+			rs.maxPhrase = max(rs.maxPhrase, len(strings.Fields(key)))
+		}
+	}
+}
+
+// mergeVariants moves every phrase in group from into group to
+func (rs *ruleSet) mergeVariants(from, to int) {
+	for key, id := range rs.variants {
+		if id == from {
+			rs.variants[key] = to
+		}
+	}
+}
+
+// normalizePhrase normalizes each word of a phrase and joins them with a
+// single space
+func (rs *ruleSet) normalizePhrase(s string) string {
+	var words []string
+	for _, w := range rs.words(s) {
+		if w = rs.normalizeWord(w); w != "" {
+			words = append(words, w)
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// normalizeWord normalizes a word using the casing rules of the language
+func (rs *ruleSet) normalizeWord(s string) string {
+	if rs.lang.casing != nil {
+		s = strings.ToLowerSpecial(rs.lang.casing, s)
+	}
+	return normalizeWord(s)
+}
+
+// wordsMatch compares two words using the rule set. In strict mode words
+// must be exactly the same, including case and punctuation.
+func (rs *ruleSet) wordsMatch(a, b string) bool {
+	if rs.strictness == strictnessStrict {
+		return exactMatch(a, b)
+	}
+
+	a = rs.normalizeWord(a)
+	b = rs.normalizeWord(b)
+
+	if a == b {
+		return true
+	}
+	for _, rule := range rs.rules {
+		if rule.match(a, b) {
+			return true
+		}
+	}
+	return rs.sameVariant(a, b)
+}
+
+// phrasesMatch compares two runs of words as a whole, for variants such as
+// "gonna" and "going to" that differ in word count. Strict mode accepts
+// no variants.
+func (rs *ruleSet) phrasesMatch(input, expected []string) bool {
+	if rs.strictness == strictnessStrict {
+		return false
+	}
+	return rs.sameVariant(rs.normalizePhrase(strings.Join(input, " ")), rs.normalizePhrase(strings.Join(expected, " ")))
+}
+
+// sameVariant returns true if two normalized phrases are in the same
+// variant group
+func (rs *ruleSet) sameVariant(a, b string) bool {
+	ga, ok := rs.variants[a]
+	if !ok {
+		return false
+	}
+	return ga == rs.variants[b]
+}
+
+// gDropped allows g-dropping (e.g., "stayin" matches "staying", "nothin"
+// matches "nothing"). Only applies to words where the "-in" form is at
+// least 5 characters to avoid matching unrelated words like "sin" and "sing"
+func gDropped(a, b string) bool {
+	if strings.HasSuffix(a, "in") && strings.HasSuffix(b, "ing") && len(a) >= 5 {
+		return a == b[:len(b)-1] // compare "stayin" with "stayin" (from "staying")
+	}
+	if strings.HasSuffix(b, "in") && strings.HasSuffix(a, "ing") && len(b) >= 5 {
+		return b == a[:len(a)-1]
+	}
+	return false
+}
+
+// typo allows one typo in words of at least typoMinLength letters, and two
+// in words twice that long
+func typo(a, b string) bool {
+	ra, rb := []rune(a), []rune(b)
+	n := min(len(ra), len(rb))
+	if n < typoMinLength {
+		return false
+	}
+	limit := 1
+	if n >= typoMinLength*2 {
+		limit = 2
+	}
+	return editDistance(ra, rb) <= limit
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRuleSetLinesMatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		match    bool
+	}{
+		{"contraction for phrase", "I'm gonna be", "I'm going to be", true},
+		{"phrase for contraction", "I'm going to be", "I'm gonna be", true},
+		{"apostrophe contraction", "'cause I love you", "because I love you", true},
+		{"til", "til the end", "until the end", true},
+		{"number digit", "2 hearts", "two hearts", true},
+		{"british spelling", "the colour of night", "the color of night", true},
+		{"unrelated phrase", "I'm gonna be", "I'm about to be", false},
+		{"typos off by default", "twinkle twinkle littel star", "twinkle twinkle little star", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := linesMatch(tt.input, tt.expected); got != tt.match {
+				t.Errorf("linesMatch(%q, %q) = %v, want %v", tt.input, tt.expected, got, tt.match)
+			}
+		})
+	}
+}
+
+func TestTypo(t *testing.T) {
+	tests := []struct {
+		a, b  string
+		match bool
+	}{
+		{"littel", "little", true},       // transposition
+		{"worle", "world", true},         // substitution
+		{"beautifl", "beautiful", true},  // deletion
+		{"beautifle", "beautiful", true}, // two edits in a long word
+		{"sin", "son", false},            // too short
+		{"wrlod", "world", false},        // two edits in a short word
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"_"+tt.b, func(t *testing.T) {
+			if got := typo(tt.a, tt.b); got != tt.match {
+				t.Errorf("typo(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.match)
+			}
+		})
+	}
+}
+
+func TestMatchConfig(t *testing.T) {
+	t.Run("empty config uses the default rules", func(t *testing.T) {
+		rs, err := matchConfig{}.ruleSet("")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rs != defaultRules {
+			t.Error("expected default rules")
+		}
+	})
+
+	t.Run("enable adds typo tolerance", func(t *testing.T) {
+		rs, err := matchConfig{Enable: []string{"typos"}}.ruleSet("")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !rs.linesMatch("twinkle twinkle litle star", "twinkle twinkle little star") {
+			t.Error("typo should be tolerated")
+		}
+	})
+
+	t.Run("disable turns off a default rule", func(t *testing.T) {
+		rs, err := matchConfig{Disable: []string{"g-dropping", "numbers"}}.ruleSet("")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rs.wordsMatch("stayin", "staying") {
+			t.Error("g-dropping should be disabled")
+		}
+		if rs.wordsMatch("2", "two") {
+			t.Error("numbers should be disabled")
+		}
+		if !rs.wordsMatch("'cause", "because") {
+			t.Error("contractions should still be enabled")
+		}
+	})
+
+	t.Run("custom variants", func(t *testing.T) {
+		rs, err := matchConfig{Variants: [][]string{{"ooh la la", "ooh lala"}, {"baby", "babe"}}}.ruleSet("")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !rs.linesMatch("ooh lala my babe", "ooh la la my baby") {
+			t.Error("custom variants should match")
+		}
+	})
+
+	t.Run("custom variant joins a builtin group", func(t *testing.T) {
+		rs, err := matchConfig{Variants: [][]string{{"because", "coz"}}}.ruleSet("")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !rs.wordsMatch("coz", "'cause") {
+			t.Error("coz should join the because group")
+		}
+	})
+
+	t.Run("unknown rule is an error", func(t *testing.T) {
+		if _, err := (matchConfig{Enable: []string{"telepathy"}}).ruleSet(""); err == nil {
+			t.Error("expected error for unknown rule")
+		}
+		if _, err := (matchConfig{Disable: []string{"telepathy"}}).ruleSet(""); err == nil {
+			t.Error("expected error for unknown rule")
+		}
+	})
+}
+
+func TestReadFileMatchConfig(t *testing.T) {
+	t.Run("front matter overrides are used by the model", func(t *testing.T) {
+		path := writeLyrics(t, "---\nmatch:\n  variants:\n    - [mama, mother]\n---\nmama told me\n")
+
+		meta, lines, err := readFile(path)
+		if err != nil {
+			t.Fatalf("readFile error: %v", err)
+		}
+		m := initialModel(meta, lines)
+		if !m.rules.linesMatch("mother told me", lines[0]) {
+			t.Error("front matter variant should be accepted")
+		}
+	})
+
+	t.Run("language selects the matching behavior", func(t *testing.T) {
+		path := writeLyrics(t, "---\nlanguage: fr\n---\nl'été est là\n")
+
+		meta, lines, err := readFile(path)
+		if err != nil {
+			t.Fatalf("readFile error: %v", err)
+		}
+		m := initialModel(meta, lines)
+		if m.rules.linesMatch("l'ete est la", lines[0]) {
+			t.Error("accents should count in French")
+		}
+	})
+
+	t.Run("strictness from front matter", func(t *testing.T) {
+		path := writeLyrics(t, "---\nmatch:\n  strictness: strict\n---\nHello, world\n")
+
+		meta, lines, err := readFile(path)
+		if err != nil {
+			t.Fatalf("readFile error: %v", err)
+		}
+		m := initialModel(meta, lines)
+		if m.rules.linesMatch("hello world", lines[0]) {
+			t.Error("strict front matter should count case and punctuation")
+		}
+	})
+
+	t.Run("language that isn't a tag gets the default matching", func(t *testing.T) {
+		path := writeLyrics(t, "---\nlanguage: English\n---\nCafé\n")
+
+		meta, lines, err := readFile(path)
+		if err != nil {
+			t.Fatalf("readFile error: %v", err)
+		}
+		if meta.Language != "English" {
+			t.Errorf("Language = %q, want English kept", meta.Language)
+		}
+		m := initialModel(meta, lines)
+		if !m.rules.linesMatch("cafe", lines[0]) {
+			t.Error("default matching should ignore accents")
+		}
+	})
+
+	t.Run("unknown rule is reported as invalid front matter", func(t *testing.T) {
+		path := writeLyrics(t, "---\nmatch:\n  enable: [telepathy]\n---\nline\n")
+
+		_, _, err := readFile(path)
+		if err == nil || !strings.Contains(err.Error(), "invalid YAML front matter") {
+			t.Errorf("err = %v, want invalid YAML front matter", err)
+		}
+	})
+}
@@ -0,0 +1,144 @@
+package main
+
+import "math"
+
+// hintPenalties is the fraction of a line's credit lost for each hint level
+var hintPenalties = []float64{0, 0.25, 0.5}
+
+// lineScore is the partial credit earned for a single typed line
+type lineScore struct {
+	words      int     // number of expected words
+	correct    int     // expected words typed correctly
+	extra      int     // input words with no expected counterpart
+	similarity float64 // character-level similarity from 0 to 1
+	hint       int     // highest hint level used
+}
+
+// scoreLine scores a line using the default rules
+func scoreLine(input, expected string, hint int) lineScore {
+	return defaultRules.scoreLine(input, expected, hint)
+}
+
+// scoreLine scores input against expected. Word accuracy comes from the
+// same alignment used by linesMatch, and character similarity gives some
+// credit for near misses such as typos. Lines with alternatives are scored
+// against the alternative that earns the most credit.
+func (rs *ruleSet) scoreLine(input, expected string, hint int) lineScore {
+	var best lineScore
+	for i, v := range parseLyric(expected).variants {
+		if s := rs.scoreVariant(input, v, hint); i == 0 || s.value() > best.value() {
+			best = s
+		}
+	}
+	return best
+}
+
+// scoreVariant scores input against a single accepted form of a line
+func (rs *ruleSet) scoreVariant(input, expected string, hint int) lineScore {
+	s := lineScore{
+		words:      len(rs.words(expected)),
+		similarity: similarity(normalize(input), normalize(expected)),
+		hint:       hint,
+	}
+	inputWords, words := rs.words(input), rs.words(expected)
+	if rs.reordered(inputWords, words) {
+		s.correct = s.words
+		return s
+	}
+	for _, op := range rs.alignWords(inputWords, words) {
+		switch op.kind {
+		case opMatch:
+			s.correct += len(rs.words(op.expected))
+		case opInsert:
+			s.extra++
+		}
+	}
+	return s
+}
+
+// wordAccuracy returns the fraction of words typed correctly, counting
+// extra words against the line. An unscored line has no accuracy.
+func (s lineScore) wordAccuracy() float64 {
+	if s.words+s.extra == 0 {
+		return 0
+	}
+	return float64(s.correct) / float64(s.words+s.extra)
+}
+
+// value returns the credit for the line from 0 to 1: the average of word
+// accuracy and character similarity, reduced by any hint penalty.
+func (s lineScore) value() float64 {
+	penalty := hintPenalties[min(s.hint, len(hintPenalties)-1)]
+	return (s.wordAccuracy() + s.similarity) / 2 * (1 - penalty)
+}
+
+// allOrNothing is the credit for a line that is picked rather than typed,
+// where there is no partial credit
+func allOrNothing(correct bool) lineScore {
+	if !correct {
+		return lineScore{words: 1}
+	}
+	return lineScore{words: 1, correct: 1, similarity: 1}
+}
+
+// runScore totals the scores of every line in a run
+type runScore struct {
+	total   int     // lines scored, excluding section headers
+	correct int     // lines that matched exactly
+	points  float64 // sum of partial credit
+}
+
+// add adds a scored line to the run
+func (r *runScore) add(s lineScore, correct bool) {
+	r.total++
+	if correct {
+		r.correct++
+	}
+	r.points += s.value()
+}
+
+// percent returns the partial credit for the run as a rounded percentage
+func (r runScore) percent() int {
+	if r.total == 0 {
+		return 0
+	}
+	return int(math.Round(r.points / float64(r.total) * 100))
+}
+
+// similarity returns 1 minus the normalized edit distance between the
+// runes of a and b.
+func similarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) == 0 && len(rb) == 0 {
+		return 1
+	}
+	return 1 - float64(editDistance(ra, rb))/float64(max(len(ra), len(rb)))
+}
+
+// editDistance returns the optimal string alignment distance between two
+// rune slices: the Levenshtein distance, with swapping two adjacent runes
+// counted as a single edit since it's such a common typo.
+func editDistance(a, b []rune) int {
+	// d[i][j] is the distance between a[:i] and b[:j]
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j-1]+cost, d[i-1][j]+1, d[i][j-1]+1)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestScoreLine(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		hint     int
+		want     float64
+	}{
+		{"exact match", "up above the world", "Up above the world", 0, 1},
+		{"nothing typed", "", "up above the world", 0, 0},
+		{"word hint penalty", "up above the world", "up above the world", 1, 0.75},
+		{"full line hint penalty", "up above the world", "up above the world", 2, 0.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := scoreLine(tt.input, tt.expected, tt.hint).value()
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("scoreLine(%q, %q, %d).value() = %v, want %v", tt.input, tt.expected, tt.hint, got, tt.want)
+			}
+		})
+	}
+
+	t.Run("missing word earns partial credit", func(t *testing.T) {
+		s := scoreLine("up above world", "up above the world", 0)
+		if s.correct != 3 || s.words != 4 {
+			t.Errorf("correct = %d, words = %d, want 3, 4", s.correct, s.words)
+		}
+		if v := s.value(); v <= 0.5 || v >= 1 {
+			t.Errorf("value() = %v, want between 0.5 and 1", v)
+		}
+	})
+
+	t.Run("typo scores higher than wrong word", func(t *testing.T) {
+		typo := scoreLine("up above the wrold", "up above the world", 0).value()
+		wrong := scoreLine("up above the ocean", "up above the world", 0).value()
+		if typo <= wrong {
+			t.Errorf("typo = %v, wrong = %v, typo should score higher", typo, wrong)
+		}
+	})
+
+	t.Run("extra words count against the line", func(t *testing.T) {
+		s := scoreLine("up above the whole world", "up above the world", 0)
+		if got := s.wordAccuracy(); got != 0.8 {
+			t.Errorf("wordAccuracy() = %v, want 0.8", got)
+		}
+	})
+}
+
+func TestRunScore(t *testing.T) {
+	var r runScore
+	r.add(scoreLine("line one", "line one", 0), true)
+	r.add(scoreLine("", "line two", 0), false)
+
+	if r.total != 2 || r.correct != 1 {
+		t.Errorf("total = %d, correct = %d, want 2, 1", r.total, r.correct)
+	}
+	if got := r.percent(); got != 50 {
+		t.Errorf("percent() = %d, want 50", got)
+	}
+	if got := (runScore{}).percent(); got != 0 {
+		t.Errorf("empty percent() = %d, want 0", got)
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"", "", 1},
+		{"abc", "abc", 1},
+		{"abc", "", 0},
+		{"world", "wrold", 0.8},
+		{"world", "word", 0.8},
+		{"café", "cafe", 0.75},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"_"+tt.b, func(t *testing.T) {
+			if got := similarity(tt.a, tt.b); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("similarity(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/rivo/uniseg"
+	"golang.org/x/text/unicode/norm"
+)
+
+// tokenizing is how a line is split into the words it's compared by
+type tokenizing int
+
+const (
+	tokenizeAuto   tokenizing = iota // spaces, plus characters in unspaced scripts
+	tokenizeSpaces                   // spaces only
+	tokenizeChars                    // spaces, plus characters in any non-Latin script
+)
+
+// unspacedLanguages lists the languages written without spaces between
+// words, by primary language subtag
+var unspacedLanguages = map[string]bool{
+	"zh": true, "yue": true, "ja": true, "th": true, "lo": true, "km": true, "my": true,
+}
+
+// unspacedScripts lists the scripts written without spaces between words
+var unspacedScripts = []*unicode.RangeTable{
+	unicode.Han, unicode.Hiragana, unicode.Katakana,
+	unicode.Thai, unicode.Lao, unicode.Khmer, unicode.Myanmar,
+}
+
+// language is how matching treats the language a song is written in, set
+// by the "language" key of the front matter
+type language struct {
+	code     string              // primary subtag, e.g. "ja", empty if not declared
+	tokenize tokenizing          // how lines are split into words
+	casing   unicode.SpecialCase // language specific lowercasing, if any
+}
+
+// parseLanguage parses a language tag such as "en", "pt-BR" or "ja". Only
+// the primary subtag affects matching.
+func parseLanguage(tag string) (language, error) {
+	tag = strings.TrimSpace(tag)
+	if tag == "" {
+		return language{}, nil
+	}
+
+	code := strings.ToLower(tag)
+	if i := strings.IndexAny(code, "-_"); i >= 0 {
+		code = code[:i]
+	}
+	if len(code) < 2 || len(code) > 3 || strings.Trim(code, "abcdefghijklmnopqrstuvwxyz") != "" {
+		return language{}, fmt.Errorf("invalid language %q", tag)
+	}
+
+	lang := language{code: code, tokenize: tokenizeSpaces}
+	if unspacedLanguages[code] {
+		lang.tokenize = tokenizeChars
+	}
+	if code == "tr" || code == "az" {
+		// Dotted and dotless i are different letters
+		lang.casing = unicode.TurkishCase
+	}
+	return lang, nil
+}
+
+// foldsAccents returns true if accents are ignored by default. Accents
+// rarely change an English word, but in most other languages they tell
+// words apart.
+func (l language) foldsAccents() bool {
+	return l.code == "" || l.code == "en"
+}
+
+// unspaced returns true if r is written without spaces around it, so each
+// character is compared as a word of its own
+func (l language) unspaced(r rune) bool {
+	switch l.tokenize {
+	case tokenizeSpaces:
+		return false
+	case tokenizeChars:
+		if unicode.IsLetter(r) && !unicode.In(r, unicode.Latin, unicode.Greek, unicode.Cyrillic) {
+			return true
+		}
+	}
+	// The prolonged sound mark is shared by hiragana and katakana
+	return unicode.In(r, unspacedScripts...) || r == 'ー' || r == 'ｰ'
+}
+
+// hasUnspaced returns true if any character of s is unspaced
+func (l language) hasUnspaced(s string) bool {
+	return strings.IndexFunc(s, l.unspaced) >= 0
+}
+
+// words splits s into the words it's compared by. Text is split at spaces,
+// and runs of unspaced script are split into characters, with punctuation
+// kept with the character beside it.
+func (rs *ruleSet) words(s string) []string {
+	fields := strings.Fields(s)
+	if rs.lang.tokenize == tokenizeSpaces {
+		return fields
+	}
+
+	var words []string
+	for _, f := range fields {
+		if !rs.lang.hasUnspaced(f) {
+			words = append(words, f)
+			continue
+		}
+		words = append(words, rs.splitUnspaced(f)...)
+	}
+	return words
+}
+
+// splitUnspaced splits a field containing unspaced script into characters,
+// keeping any spaced words in it whole. Characters are split by grapheme
+// cluster, so combining marks stay with their base character.
+func (rs *ruleSet) splitUnspaced(field string) []string {
+	var words []string
+	var word strings.Builder // spaced word or leading punctuation
+	flush := func() {
+		if word.Len() == 0 {
+			return
+		}
+		if normalizeWord(word.String()) == "" && len(words) > 0 {
+			// Trailing punctuation goes with the character before it
+			words[len(words)-1] += word.String()
+		} else {
+			words = append(words, word.String())
+		}
+		word.Reset()
+	}
+
+	g := uniseg.NewGraphemes(field)
+	for g.Next() {
+		c := g.Str()
+		switch {
+		case rs.lang.unspaced(g.Runes()[0]):
+			if word.Len() > 0 && normalizeWord(word.String()) == "" {
+				// Opening punctuation goes with the character after it
+				c = word.String() + c
+				word.Reset()
+			}
+			flush()
+			words = append(words, c)
+		case word.Len() == 0 && len(words) > 0 && normalizeWord(c) == "":
+			words[len(words)-1] += c
+		default:
+			word.WriteString(c)
+		}
+	}
+	flush()
+	return words
+}
+
+// joinWords joins words back into a line, without spaces between
+// characters of unspaced script
+func (rs *ruleSet) joinWords(words []string) string {
+	var b strings.Builder
+	for i, w := range words {
+		if i > 0 && rs.spaceBetween(words[i-1], w) {
+			b.WriteString(" ")
+		}
+		b.WriteString(w)
+	}
+	return b.String()
+}
+
+// spaceBetween returns true if two adjacent words are written with a space
+// between them
+func (rs *ruleSet) spaceBetween(a, b string) bool {
+	return !rs.lang.hasUnspaced(a) || !rs.lang.hasUnspaced(b)
+}
+
+// partialWord returns true if the user is in the middle of typing the last
+// word of input. Characters of unspaced script are always whole words.
+func (rs *ruleSet) partialWord(input string) bool {
+	if input == "" || strings.HasSuffix(input, " ") {
+		return false
+	}
+	runes := []rune(input)
+	return !rs.lang.unspaced(runes[len(runes)-1])
+}
+
+// accentFolds maps letters with no decomposition to their unaccented form
+var accentFolds = map[rune]string{
+	'ß': "ss", 'æ': "ae", 'œ': "oe", 'ø': "o", 'ł': "l", 'đ': "d", 'ð': "d", 'þ': "th", 'ı': "i",
+}
+
+// foldAccents removes the accents from Latin, Greek and Cyrillic letters of
+// a normalized word, so "café" becomes "cafe". Marks in other scripts are
+// usually vowels or tones and are kept.
+func foldAccents(s string) string {
+	var b strings.Builder
+	var base rune
+	for _, r := range norm.NFKD.String(s) {
+		if unicode.Is(unicode.Mn, r) {
+			if unicode.In(base, unicode.Latin, unicode.Greek, unicode.Cyrillic) {
+				continue
+			}
+		} else {
+			base = r
+		}
+		if fold, ok := accentFolds[r]; ok {
+			b.WriteString(fold)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return norm.NFC.String(b.String())
+}
+
+// accentsFolded allows words that differ only in accents, e.g. "cafe" for
+// "café"
+func accentsFolded(a, b string) bool {
+	return foldAccents(a) == foldAccents(b)
+}
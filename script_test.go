@@ -0,0 +1,200 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseLanguage(t *testing.T) {
+	tests := []struct {
+		tag      string
+		code     string
+		tokenize tokenizing
+		wantErr  bool
+	}{
+		{"", "", tokenizeAuto, false},
+		{"en", "en", tokenizeSpaces, false},
+		{"pt-BR", "pt", tokenizeSpaces, false},
+		{"zh_Hant", "zh", tokenizeChars, false},
+		{"JA", "ja", tokenizeChars, false},
+		{"english", "", tokenizeAuto, true},
+		{"e", "", tokenizeAuto, true},
+		{"e1", "", tokenizeAuto, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			lang, err := parseLanguage(tt.tag)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseLanguage(%q) error = %v, wantErr %v", tt.tag, err, tt.wantErr)
+			}
+			if lang.code != tt.code || lang.tokenize != tt.tokenize {
+				t.Errorf("parseLanguage(%q) = %q/%v, want %q/%v", tt.tag, lang.code, lang.tokenize, tt.code, tt.tokenize)
+			}
+		})
+	}
+}
+
+func TestWords(t *testing.T) {
+	rules := func(tag string) *ruleSet {
+		rs, err := matchConfig{}.ruleSet(tag)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return rs
+	}
+
+	tests := []struct {
+		name  string
+		lang  string
+		input string
+		want  []string
+	}{
+		{"spaced words", "", "Twinkle, twinkle little star", []string{"Twinkle,", "twinkle", "little", "star"}},
+		{"Chinese by character", "", "我爱你", []string{"我", "爱", "你"}},
+		{"punctuation stays with characters", "", "「愛してる」。", []string{"「愛", "し", "て", "る」。"}},
+		{"Latin words inside Japanese", "", "Baby愛してる", []string{"Baby", "愛", "し", "て", "る"}},
+		{"prolonged sound mark", "", "ラーメン", []string{"ラ", "ー", "メ", "ン"}},
+		{"Thai keeps combining vowels", "", "ที่รัก", []string{"ที่", "รั", "ก"}},
+		{"spaced language turns the fallback off", "en", "我爱你", []string{"我爱你"}},
+		{"unspaced language splits other scripts", "ja", "사랑해", []string{"사", "랑", "해"}},
+		{"Korean is spaced by default", "", "사랑해 요", []string{"사랑해", "요"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rules(tt.lang).words(tt.input); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("words(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+
+	t.Run("joinWords leaves out spaces between characters", func(t *testing.T) {
+		rs := rules("")
+		if got := rs.joinWords([]string{"Baby", "愛", "し", "て", "る"}); got != "Baby 愛してる" {
+			t.Errorf("joinWords = %q, want %q", got, "Baby 愛してる")
+		}
+	})
+}
+
+func TestFoldAccents(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"café", "cafe"},
+		{"naïve", "naive"},
+		{"straße", "strasse"},
+		{"øre", "ore"},
+		{"ёлка", "елка"},
+		{"ά", "α"},
+		{"नमस्ते", "नमस्ते"}, // vowel signs aren't accents
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := foldAccents(tt.input); got != tt.want {
+				t.Errorf("foldAccents(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLanguageMatching(t *testing.T) {
+	rules := func(t *testing.T, c matchConfig, tag string) *ruleSet {
+		t.Helper()
+		rs, err := c.ruleSet(tag)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return rs
+	}
+
+	t.Run("accents are folded by default", func(t *testing.T) {
+		if !linesMatch("cafe au lait", "Café au lait") {
+			t.Error("accents should be ignored without a language")
+		}
+		if !rules(t, matchConfig{}, "en").linesMatch("cafe au lait", "Café au lait") {
+			t.Error("accents should be ignored in English")
+		}
+	})
+
+	t.Run("accents count in other languages", func(t *testing.T) {
+		rs := rules(t, matchConfig{}, "es")
+		if rs.linesMatch("el ano", "el año") {
+			t.Error("accents should count in Spanish")
+		}
+		if !rs.linesMatch("el año", "el año") {
+			t.Error("same line should match")
+		}
+	})
+
+	t.Run("accents can be folded explicitly", func(t *testing.T) {
+		if !rules(t, matchConfig{Enable: []string{"accents"}}, "es").linesMatch("el ano", "el año") {
+			t.Error("enabled accents rule should fold accents")
+		}
+		if rules(t, matchConfig{Disable: []string{"accents"}}, "").linesMatch("cafe", "café") {
+			t.Error("disabled accents rule should not fold accents")
+		}
+	})
+
+	t.Run("composed and decomposed forms match", func(t *testing.T) {
+		rs := rules(t, matchConfig{}, "fr")
+		if !rs.linesMatch("l'e\u0301te\u0301", "l'été") {
+			t.Error("decomposed accents should match composed ones")
+		}
+	})
+
+	t.Run("unspaced lines match with or without spaces", func(t *testing.T) {
+		rs := rules(t, matchConfig{}, "ja")
+		if !rs.linesMatch("きらきら ひかる", "きらきらひかる") {
+			t.Error("spaces between characters should be ignored")
+		}
+		if !linesMatch("我爱你", "我爱你。") {
+			t.Error("Chinese should match without a language")
+		}
+		if linesMatch("我爱他", "我爱你") {
+			t.Error("a wrong character should not match")
+		}
+	})
+
+	t.Run("diff is by character", func(t *testing.T) {
+		diff := formatDiff("我爱他", "我爱你")
+		if !strings.Contains(diff, "他") || !strings.Contains(diff, "(你)") {
+			t.Errorf("diff should mark the wrong character, got %q", diff)
+		}
+		if strings.Contains(diff, " ") {
+			t.Errorf("diff should not space out characters, got %q", diff)
+		}
+	})
+
+	t.Run("score counts characters", func(t *testing.T) {
+		s := scoreLine("我爱他", "我爱你", 0)
+		if s.words != 3 || s.correct != 2 {
+			t.Errorf("score = %d/%d, want 2/3", s.correct, s.words)
+		}
+	})
+
+	t.Run("hint after a whole character is the next one", func(t *testing.T) {
+		if got := getNextWordHint("我", "我爱你"); got != "爱" {
+			t.Errorf("hint = %q, want %q", got, "爱")
+		}
+	})
+
+	t.Run("variants across scripts", func(t *testing.T) {
+		rs := rules(t, matchConfig{Variants: [][]string{{"東京", "とうきょう"}}}, "ja")
+		if !rs.linesMatch("とうきょうへ", "東京へ") {
+			t.Error("variant with a different number of characters should match")
+		}
+	})
+
+	t.Run("Turkish dotless i", func(t *testing.T) {
+		if rules(t, matchConfig{}, "de").linesMatch("ışık", "IŞIK") {
+			t.Error("default lowercasing should not match dotless i")
+		}
+		if !rules(t, matchConfig{}, "tr").linesMatch("ışık", "IŞIK") {
+			t.Error("Turkish casing should match dotless i")
+		}
+	})
+}
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// sectionListHeight is the most sections shown at once on the section
+// select screen
+const sectionListHeight = 10
+
+// sectionList is the state of the section select screen: a scrolling list
+// of sections that can be filtered by name, with several picked at once
+type sectionList struct {
+	cursor    int        // row the cursor is on
+	top       int        // first row shown
+	picked    []bool     // sections picked to practice together, by index
+	filter    lineEditor // text the sections are filtered by
+	filtering bool       // typing a filter
+}
+
+// sectionRows returns the sections listed on the section select screen,
+// with -1 for the "All sections" row when there's no filter. Nested sections
+// are indented under the section they're in, and picking a section picks
+// everything in it. A section sung more than once, such as a chorus, is only
+// listed the first time.
+func (m model) sectionRows() []int {
+	filter := normalize(m.sectionList.filter.Value())
+	var rows []int
+	if filter == "" {
+		rows = append(rows, -1)
+	}
+	for i, sec := range m.sections {
+		if m.firstOf[i] == i && strings.Contains(normalize(sec.name), filter) {
+			rows = append(rows, i)
+		}
+	}
+	return rows
+}
+
+// newSectionList returns the section list as it opens, with the sections
+// named in the front matter already picked
+func (m model) newSectionList() sectionList {
+	var l sectionList
+	sections, _ := m.findSections(m.meta.Recite.Section)
+	if len(sections) > 0 {
+		l.picked = make([]bool, len(m.sections))
+	}
+	for _, s := range sections {
+		// A repeat is listed as the first time it's sung
+		l.picked[m.firstOf[s]] = true
+	}
+	return l
+}
+
+// pickedSections returns the sections picked on the section select screen
+func (m model) pickedSections() []int {
+	var picked []int
+	for i, p := range m.sectionList.picked {
+		if p {
+			picked = append(picked, i)
+		}
+	}
+	return picked
+}
+
+func (m model) handleSectionSelectInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.sectionList.filtering {
+		return m.handleSectionFilterInput(msg)
+	}
+
+	switch msg.Type {
+	case tea.KeyCtrlC, tea.KeyEsc:
+		return m, tea.Quit
+
+	case tea.KeyUp:
+		m.moveSectionCursor(-1, false)
+	case tea.KeyDown:
+		m.moveSectionCursor(1, false)
+	case tea.KeyShiftUp:
+		m.moveSectionCursor(-1, true)
+	case tea.KeyShiftDown:
+		m.moveSectionCursor(1, true)
+	case tea.KeyPgUp:
+		m.moveSectionCursor(-sectionListHeight, false)
+	case tea.KeyPgDown:
+		m.moveSectionCursor(sectionListHeight, false)
+
+	case tea.KeySpace:
+		if rows := m.sectionRows(); len(rows) > 0 && rows[m.sectionList.cursor] >= 0 {
+			s := rows[m.sectionList.cursor]
+			m.pickSection(s, !slices.Contains(m.pickedSections(), s))
+		}
+
+	case tea.KeyEnter:
+		return m, m.startSections()
+
+	case tea.KeyRunes:
+		key := string(msg.Runes)
+		switch {
+		case key == "/":
+			m.sectionList.filtering = true
+		case (key == "o" || key == "O") && m.hasRepeats():
+			m.once = !m.once
+		case key == "a" || key == "A":
+			// All sections
+			m.selectSection(-1)
+			return m, m.beginRun()
+		case len(key) == 1 && key[0] >= '1' && key[0] <= '9':
+			// Number keys 1-9 select one of the first nine sections
+			if idx := int(key[0] - '1'); idx < len(m.sections) {
+				m.selectSection(idx)
+				return m, m.beginRun()
+			}
+		}
+	}
+
+	return m, nil
+}
+
+// handleSectionFilterInput edits the filter. Enter keeps it and Esc clears
+// it, going back to moving through the list either way.
+func (m model) handleSectionFilterInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyCtrlC:
+		return m, tea.Quit
+	case tea.KeyEsc:
+		m.sectionList.filter.Reset()
+		m.sectionList.filtering = false
+		m.sectionList.cursor, m.sectionList.top = 0, 0
+	case tea.KeyEnter:
+		m.sectionList.filtering = false
+	case tea.KeyUp:
+		m.moveSectionCursor(-1, false)
+	case tea.KeyDown:
+		m.moveSectionCursor(1, false)
+	default:
+		if m.sectionList.filter.update(msg) {
+			m.sectionList.cursor, m.sectionList.top = 0, 0
+		}
+	}
+	return m, nil
+}
+
+// moveSectionCursor moves the cursor by delta rows, scrolling to keep it in
+// view. With pick set, every section passed over is picked, so a range can
+// be picked by holding shift.
+func (m *model) moveSectionCursor(delta int, pick bool) {
+	rows := m.sectionRows()
+	if len(rows) == 0 {
+		return
+	}
+	l := &m.sectionList
+	to := min(max(l.cursor+delta, 0), len(rows)-1)
+	if pick {
+		for r := min(l.cursor, to); r <= max(l.cursor, to); r++ {
+			if rows[r] >= 0 {
+				m.pickSection(rows[r], true)
+			}
+		}
+	}
+
+	l.cursor = to
+	if l.cursor < l.top {
+		l.top = l.cursor
+	}
+	if l.cursor >= l.top+sectionListHeight {
+		l.top = l.cursor - sectionListHeight + 1
+	}
+}
+
+// pickSection picks or unpicks a section. The picks are copied rather than
+// changed in place, since copies of the model share them.
+func (m *model) pickSection(s int, on bool) {
+	picked := make([]bool, len(m.sections))
+	copy(picked, m.sectionList.picked)
+	picked[s] = on
+	m.sectionList.picked = picked
+}
+
+// startSections starts a run with the picked sections, or if none are
+// picked the row under the cursor
+func (m *model) startSections() tea.Cmd {
+	picked := m.pickedSections()
+	if len(picked) == 0 {
+		rows := m.sectionRows()
+		if len(rows) == 0 {
+			return nil
+		}
+		if s := rows[m.sectionList.cursor]; s >= 0 {
+			picked = []int{s}
+		}
+	}
+	m.selectSections(picked)
+	return m.beginRun()
+}
+
+// writeSectionSelect writes the section list along with the filter and the
+// sections picked so far
+func (m model) writeSectionSelect(b *strings.Builder) {
+	l := m.sectionList
+	b.WriteString(boldStyle.Render("Select Section:"))
+	b.WriteString("\n\n")
+
+	if l.filtering {
+		b.WriteString("  Filter: " + l.filter.View() + "\n\n")
+	} else if l.filter.Value() != "" {
+		b.WriteString(dimStyle.Render("  Filter: "+l.filter.Value()) + "\n\n")
+	}
+
+	rows := m.sectionRows()
+	if len(rows) == 0 {
+		b.WriteString(dimStyle.Render("  No sections match"))
+		b.WriteString("\n")
+	}
+	if l.top > 0 {
+		b.WriteString(dimStyle.Render(fmt.Sprintf("  ↑ %d more", l.top)))
+		b.WriteString("\n")
+	}
+	end := min(l.top+sectionListHeight, len(rows))
+	for r := l.top; r < end; r++ {
+		prefix := "  "
+		if r == l.cursor {
+			prefix = "> "
+		}
+		s := rows[r]
+		if s < 0 {
+			if m.once {
+				b.WriteString(prefix + "    a. All sections, repeats cut to their first line\n")
+			} else {
+				b.WriteString(prefix + "    a. All sections\n")
+			}
+			continue
+		}
+		box := "[ ]"
+		if slices.Contains(m.pickedSections(), s) {
+			box = "[x]"
+		}
+		indent := strings.Repeat("  ", m.sections[s].depth)
+		name := m.sections[s].name
+		if n := m.timesSung(s); n > 1 {
+			name += fmt.Sprintf(" (×%d)", n)
+		}
+		b.WriteString(fmt.Sprintf("%s%s %s%d. %s\n", prefix, box, indent, s+1, name))
+	}
+	if end < len(rows) {
+		b.WriteString(dimStyle.Render(fmt.Sprintf("  ↓ %d more", len(rows)-end)))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+
+	if picked := m.pickedSections(); len(picked) > 0 {
+		names := make([]string, len(picked))
+		for i, s := range picked {
+			names[i] = m.sections[s].name
+		}
+		b.WriteString("Picked: " + strings.Join(names, " + ") + "\n")
+	}
+
+	if l.filtering {
+		b.WriteString(dimStyle.Render("Type to filter, enter to keep the filter, esc to clear it"))
+		return
+	}
+	b.WriteString(dimStyle.Render("↑/↓ to move, space to pick, shift+↑/↓ to pick a range, / to filter"))
+	b.WriteString("\n")
+	if m.hasRepeats() {
+		if m.once {
+			b.WriteString(dimStyle.Render("o to sing every repeat in full"))
+		} else {
+			b.WriteString(dimStyle.Render("o to sing repeated sections once, typing just the first line of each repeat"))
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("Press enter to start, a for all sections or 1-9 for one: ")
+}
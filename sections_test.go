@@ -0,0 +1,361 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestSectionList(t *testing.T) {
+	// A long poem with more sections than number keys
+	var lines []string
+	for i := 1; i <= 12; i++ {
+		lines = append(lines, fmt.Sprintf("# Stanza %d", i), fmt.Sprintf("Line of stanza %d", i))
+	}
+	lines[22] = "# Envoi"
+
+	newList := func() model {
+		m := initialModel(metadata{}, lines)
+		m.chooseMode(modePractice)
+		return m
+	}
+	key := func(m model, k tea.KeyType) model {
+		newModel, _ := m.Update(tea.KeyMsg{Type: k})
+		return newModel.(model)
+	}
+	typeText := func(m model, s string) model {
+		for _, r := range s {
+			m = press(m, r)
+		}
+		return m
+	}
+
+	t.Run("cursor reaches sections past 9", func(t *testing.T) {
+		m := newList()
+		for i := 0; i < 12; i++ {
+			m = key(m, tea.KeyDown)
+		}
+		m = key(m, tea.KeyEnter)
+		if m.state != stateTyping || m.sectionName() != "Envoi" {
+			t.Errorf("state = %v, section = %q, want typing Envoi", m.state, m.sectionName())
+		}
+	})
+
+	t.Run("enter on the first row practices everything", func(t *testing.T) {
+		m := key(newList(), tea.KeyEnter)
+		if m.state != stateTyping || m.selected != nil || len(m.lines) != len(lines) {
+			t.Errorf("state = %v, selected = %v, want the whole song", m.state, m.selected)
+		}
+	})
+
+	t.Run("list scrolls", func(t *testing.T) {
+		m := newList()
+		view := m.View()
+		if !strings.Contains(view, "> ") || !strings.Contains(view, "a. All sections") || !strings.Contains(view, "↓ 3 more") {
+			t.Errorf("view should show the top of the list, got: %s", view)
+		}
+		if strings.Contains(view, "Envoi") {
+			t.Errorf("view should not show the last section yet, got: %s", view)
+		}
+
+		for i := 0; i < 12; i++ {
+			m = key(m, tea.KeyDown)
+		}
+		view = m.View()
+		if !strings.Contains(view, "> [ ] 12. Envoi") || !strings.Contains(view, "↑ 3 more") {
+			t.Errorf("view should scroll to the cursor, got: %s", view)
+		}
+
+		m = key(m, tea.KeyPgUp)
+		if m.sectionList.cursor != 2 || m.sectionList.top != 2 {
+			t.Errorf("cursor = %d, top = %d, want 2, 2 after page up", m.sectionList.cursor, m.sectionList.top)
+		}
+	})
+
+	t.Run("filter by name", func(t *testing.T) {
+		m := press(newList(), '/')
+		m = typeText(m, "stanza 1")
+		if rows := m.sectionRows(); !reflect.DeepEqual(rows, []int{0, 9, 10}) {
+			t.Errorf("rows = %v, want stanzas 1, 10 and 11", rows)
+		}
+		if view := m.View(); !strings.Contains(view, "Filter: stanza 1_") || strings.Contains(view, "All sections") {
+			t.Errorf("view should show the filter, got: %s", view)
+		}
+
+		m = key(m, tea.KeyDown)
+		m = key(m, tea.KeyEnter) // keep the filter
+		m = key(m, tea.KeyEnter)
+		if m.sectionName() != "Stanza 10" {
+			t.Errorf("section = %q, want Stanza 10", m.sectionName())
+		}
+	})
+
+	t.Run("letters filter rather than select", func(t *testing.T) {
+		m := press(newList(), '/')
+		m = typeText(m, "a")
+		if m.state != stateSectionSelect || m.sectionList.filter.Value() != "a" {
+			t.Errorf("state = %v, filter = %q, want a filter", m.state, m.sectionList.filter.Value())
+		}
+	})
+
+	t.Run("esc clears the filter", func(t *testing.T) {
+		m := press(newList(), '/')
+		m = typeText(m, "envoi")
+		m = key(m, tea.KeyEsc)
+		if m.state != stateSectionSelect || m.sectionList.filtering || len(m.sectionRows()) != 13 {
+			t.Errorf("state = %v, rows = %d, want the full list", m.state, len(m.sectionRows()))
+		}
+	})
+
+	t.Run("no match", func(t *testing.T) {
+		m := press(newList(), '/')
+		m = typeText(m, "chorus")
+		if view := m.View(); !strings.Contains(view, "No sections match") {
+			t.Errorf("view should say nothing matches, got: %s", view)
+		}
+		m = key(m, tea.KeyEnter)
+		if m = key(m, tea.KeyEnter); m.state != stateSectionSelect {
+			t.Errorf("state = %v, want to stay on the section list", m.state)
+		}
+	})
+
+	t.Run("space picks several sections", func(t *testing.T) {
+		m := newList()
+		m = key(m, tea.KeyDown)
+		m = key(m, tea.KeySpace)
+		m = key(m, tea.KeyDown)
+		m = key(m, tea.KeyDown)
+		m = key(m, tea.KeySpace)
+		if view := m.View(); !strings.Contains(view, "[x] 1. Stanza 1") || !strings.Contains(view, "Picked: Stanza 1 + Stanza 3") {
+			t.Errorf("view should show the picks, got: %s", view)
+		}
+
+		m = key(m, tea.KeyEnter)
+		if m.sectionName() != "Stanza 1 + Stanza 3" {
+			t.Errorf("section = %q, want Stanza 1 + Stanza 3", m.sectionName())
+		}
+		want := []string{lines[0], lines[1], lines[4], lines[5]}
+		if !reflect.DeepEqual(m.lines, want) {
+			t.Errorf("lines = %q, want %q", m.lines, want)
+		}
+		if m.lineIndex(2) != 4 {
+			t.Errorf("lineIndex(2) = %d, want 4", m.lineIndex(2))
+		}
+	})
+
+	t.Run("space again unpicks", func(t *testing.T) {
+		m := key(newList(), tea.KeyDown)
+		m = key(m, tea.KeySpace)
+		m = key(m, tea.KeySpace)
+		if picked := m.pickedSections(); len(picked) != 0 {
+			t.Errorf("picked = %v, want none", picked)
+		}
+	})
+
+	t.Run("shift picks a range", func(t *testing.T) {
+		m := key(newList(), tea.KeyDown)
+		m = key(m, tea.KeyDown)
+		m = key(m, tea.KeyShiftDown)
+		m = key(m, tea.KeyShiftDown)
+		if picked := m.pickedSections(); !reflect.DeepEqual(picked, []int{1, 2, 3}) {
+			t.Errorf("picked = %v, want stanzas 2 to 4", picked)
+		}
+	})
+
+	t.Run("picks are not shared with copies", func(t *testing.T) {
+		m := key(newList(), tea.KeyDown)
+		m = key(m, tea.KeySpace)
+		before := m
+		m = key(m, tea.KeyDown)
+		m = key(m, tea.KeySpace)
+		if len(before.pickedSections()) != 1 {
+			t.Errorf("earlier model picked = %v, want 1 section", before.pickedSections())
+		}
+	})
+
+	t.Run("retry keeps the picked sections", func(t *testing.T) {
+		m := newList()
+		m.selectSections([]int{3, 1})
+		if !reflect.DeepEqual(m.selected, []int{1, 3}) {
+			t.Fatalf("selected = %v, want song order", m.selected)
+		}
+		m.beginRun()
+		m.state = stateResult
+		m = press(m, 'y')
+		if m.sectionName() != "Stanza 2 + Stanza 4" || len(m.lines) != 4 {
+			t.Errorf("section = %q, %d lines, want both stanzas again", m.sectionName(), len(m.lines))
+		}
+	})
+
+	t.Run("picking every section is the whole song", func(t *testing.T) {
+		m := newList()
+		var all []int
+		for i := range m.sections {
+			all = append(all, i)
+		}
+		m.selectSections(all)
+		if m.selected != nil || m.sectionName() != "All sections" {
+			t.Errorf("selected = %v, want nil", m.selected)
+		}
+	})
+}
+
+func TestNestedSections(t *testing.T) {
+	lines := []string{
+		"# Act 1", "## Scene 1", "To be or not to be", "## Scene 2", "Get thee to a nunnery",
+		"# Act 2", "## Scene 1", "Alas poor Yorick",
+	}
+	newList := func() model {
+		m := initialModel(metadata{}, lines)
+		m.chooseMode(modePractice)
+		return m
+	}
+
+	t.Run("picker shows the tree", func(t *testing.T) {
+		view := newList().View()
+		for _, want := range []string{"[ ] 1. Act 1", "[ ]   2. Scene 1", "[ ]   3. Scene 2", "[ ] 4. Act 2"} {
+			if !strings.Contains(view, want) {
+				t.Errorf("view should contain %q, got: %s", want, view)
+			}
+		}
+	})
+
+	t.Run("picking a section practices everything in it", func(t *testing.T) {
+		m := newList()
+		m.selectSections([]int{0})
+		if !reflect.DeepEqual(m.selected, []int{0, 1, 2}) {
+			t.Errorf("selected = %v, want Act 1 and its scenes", m.selected)
+		}
+		if m.sectionName() != "Act 1" || len(m.lines) != 5 {
+			t.Errorf("section = %q, %d lines, want Act 1 with 5 lines", m.sectionName(), len(m.lines))
+		}
+	})
+
+	t.Run("a scene on its own", func(t *testing.T) {
+		m := newList()
+		m.selectSections([]int{2})
+		if m.sectionName() != "Scene 2" || !reflect.DeepEqual(m.lines, lines[3:5]) {
+			t.Errorf("section = %q, lines = %q, want just Scene 2", m.sectionName(), m.lines)
+		}
+	})
+
+	t.Run("find by path", func(t *testing.T) {
+		got, err := newList().findSections("Act 2 / Scene 1")
+		if err != nil || !reflect.DeepEqual(got, []int{4}) {
+			t.Errorf("findSections = %v, %v, want [4]", got, err)
+		}
+	})
+}
+
+func TestRepeatedSections(t *testing.T) {
+	lines := []string{
+		"# Verse 1", "Twinkle twinkle little star", "How I wonder what you are",
+		"# Chorus", "Up above the world so high", "Like a diamond in the sky",
+		"# Verse 2", "When the blazing sun is gone",
+		"# Chorus", "Up above the world so high", "Like a diamond in the sky",
+	}
+	newList := func() model {
+		m := initialModel(metadata{}, lines)
+		m.chooseMode(modePractice)
+		return m
+	}
+
+	t.Run("picker lists a repeated section once", func(t *testing.T) {
+		m := newList()
+		if rows := m.sectionRows(); !reflect.DeepEqual(rows, []int{-1, 0, 1, 2}) {
+			t.Errorf("rows = %v, want the second chorus left out", rows)
+		}
+		if view := m.View(); !strings.Contains(view, "2. Chorus (×2)") || !strings.Contains(view, "o to sing repeated sections once") {
+			t.Errorf("view should show the repeat count, got: %s", view)
+		}
+	})
+
+	t.Run("picking the chorus practices it once", func(t *testing.T) {
+		m := newList()
+		m.selectSections([]int{1})
+		if len(m.lines) != 3 {
+			t.Errorf("lines = %q, want one chorus", m.lines)
+		}
+	})
+
+	t.Run("whole song sings every repeat by default", func(t *testing.T) {
+		m := press(newList(), 'a')
+		if len(m.lines) != len(lines) {
+			t.Errorf("%d lines, want %d", len(m.lines), len(lines))
+		}
+	})
+
+	t.Run("o cuts repeats to their first line", func(t *testing.T) {
+		m := press(newList(), 'o')
+		if !m.once || !strings.Contains(m.View(), "repeats cut to their first line") {
+			t.Fatalf("once = %v, want on", m.once)
+		}
+		m = press(m, 'a')
+		want := append(slices.Clone(lines[:9]), lines[9])
+		if !reflect.DeepEqual(m.lines, want) {
+			t.Errorf("lines = %q, want %q", m.lines, want)
+		}
+		if m.lineIndex(9) != 9 {
+			t.Errorf("lineIndex(9) = %d, want 9", m.lineIndex(9))
+		}
+	})
+
+	t.Run("o does nothing without repeats", func(t *testing.T) {
+		m := initialModel(metadata{}, lines[:8])
+		m.chooseMode(modePractice)
+		if m = press(m, 'o'); m.once || strings.Contains(m.View(), "o to sing") {
+			t.Errorf("once = %v, want off for a song without repeats", m.once)
+		}
+	})
+
+	t.Run("sing-along keeps every repeat", func(t *testing.T) {
+		m := newList()
+		m.once = true
+		m.mode = modeSingAlong
+		m.selectSections(nil)
+		if len(m.lines) != len(lines) {
+			t.Errorf("%d lines, want %d", len(m.lines), len(lines))
+		}
+	})
+}
+
+func TestFindSections(t *testing.T) {
+	m := initialModel(metadata{}, []string{
+		"# Verse 1", "a", "# Pre-Chorus", "b", "# Chorus", "c", "# Verse 2", "d", "# Bridge", "e",
+	})
+
+	tests := []struct {
+		spec    string
+		want    []int
+		wantErr bool
+	}{
+		{"", nil, false},
+		{"all", nil, false},
+		{"chorus", []int{2}, false},
+		{"Pre-Chorus", []int{1}, false},
+		{"3", []int{2}, false},
+		{"2-4", []int{1, 2, 3}, false},
+		{"Verse 1, Chorus", []int{0, 2}, false},
+		{"1,4-5", []int{0, 3, 4}, false},
+		{"4-2", nil, true},
+		{"6", nil, true},
+		{"1-9", nil, true},
+		{"Outro", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := m.findSections(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("findSections(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("findSections(%q) = %v, want %v", tt.spec, got, tt.want)
+			}
+		})
+	}
+}
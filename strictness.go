@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"golang.org/x/text/unicode/norm"
+)
+
+// strictness is how closely a typed line must follow the expected one
+type strictness int
+
+const (
+	strictnessNormal  strictness = iota // ignore case and punctuation
+	strictnessStrict                    // exact text, for rehearsing word for word
+	strictnessLenient                   // also forgive typos and words out of order
+)
+
+// strictnessNames lists the strictness levels by the name used in front
+// matter and on the command line
+var strictnessNames = map[string]strictness{
+	"normal":  strictnessNormal,
+	"strict":  strictnessStrict,
+	"lenient": strictnessLenient,
+}
+
+// parseStrictness parses a strictness level name. An empty name is normal.
+func parseStrictness(name string) (strictness, error) {
+	if name == "" {
+		return strictnessNormal, nil
+	}
+	level, ok := strictnessNames[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return strictnessNormal, fmt.Errorf("unknown strictness %q, want strict, normal or lenient", name)
+	}
+	return level, nil
+}
+
+// sameWords returns true if input has the same words as expected in any
+// order, for lenient matching
+func (rs *ruleSet) sameWords(input, expected []string) bool {
+	if len(input) != len(expected) {
+		return false
+	}
+	used := make([]bool, len(input))
+	for _, e := range expected {
+		found := false
+		for i, w := range input {
+			if !used[i] && rs.wordsMatch(w, e) {
+				used[i], found = true, true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// reordered returns true if lenient matching accepts input as the
+// expected words out of order
+func (rs *ruleSet) reordered(input, expected []string) bool {
+	return rs.strictness == strictnessLenient && rs.sameWords(input, expected)
+}
+
+// exactMatch compares two words exactly, apart from how accented characters
+// are encoded
+func exactMatch(a, b string) bool {
+	return norm.NFC.String(a) == norm.NFC.String(b)
+}
+
+// writeCharDiff writes a word that differs from the expected one only in
+// case or punctuation, with the characters that differ highlighted on both
+// sides
+func writeCharDiff(b *strings.Builder, input, expected string) {
+	in, ex := []rune(norm.NFC.String(input)), []rune(norm.NFC.String(expected))
+	inKeep, exKeep := commonRunes(in, ex)
+
+	writeRuns(b, in, inKeep, greenStyle, redStyle.Underline(true))
+	b.WriteString(dimStyle.Render("("))
+	writeRuns(b, ex, exKeep, dimStyle, redStyle)
+	b.WriteString(dimStyle.Render(")"))
+}
+
+// writeRuns writes runes in runs, styling the kept runes with same and the
+// rest with differ
+func writeRuns(b *strings.Builder, runes []rune, keep []bool, same, differ lipgloss.Style) {
+	for start := 0; start < len(runes); {
+		end := start
+		for end < len(runes) && keep[end] == keep[start] {
+			end++
+		}
+		style := differ
+		if keep[start] {
+			style = same
+		}
+		b.WriteString(style.Render(string(runes[start:end])))
+		start = end
+	}
+}
+
+// commonRunes marks the runes of a and b that are part of their longest
+// common subsequence
+func commonRunes(a, b []rune) ([]bool, []bool) {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:]
+	// and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	keepA, keepB := make([]bool, len(a)), make([]bool, len(b))
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] == b[j]:
+			keepA[i], keepB[j] = true, true
+			i, j = i+1, j+1
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return keepA, keepB
+}
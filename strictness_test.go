@@ -0,0 +1,142 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseStrictness(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    strictness
+		wantErr bool
+	}{
+		{"", strictnessNormal, false},
+		{"normal", strictnessNormal, false},
+		{"strict", strictnessStrict, false},
+		{"Lenient", strictnessLenient, false},
+		{"sloppy", strictnessNormal, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseStrictness(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseStrictness(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseStrictness(%q) = %v, want %v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStrictness(t *testing.T) {
+	rules := func(t *testing.T, c matchConfig) *ruleSet {
+		t.Helper()
+		rs, err := c.ruleSet("")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return rs
+	}
+	strict := func(t *testing.T) *ruleSet { return rules(t, matchConfig{Strictness: "strict"}) }
+	lenient := func(t *testing.T) *ruleSet { return rules(t, matchConfig{Strictness: "lenient"}) }
+
+	t.Run("normal is the default", func(t *testing.T) {
+		if rs := rules(t, matchConfig{Strictness: "normal"}); !rs.linesMatch("twinkle twinkle little star", "Twinkle, twinkle, little star!") {
+			t.Error("normal should ignore case and punctuation")
+		}
+	})
+
+	t.Run("strict compares exact text", func(t *testing.T) {
+		rs := strict(t)
+		if !rs.linesMatch("Twinkle, twinkle, little star!", "Twinkle, twinkle, little star!") {
+			t.Error("exact line should match")
+		}
+		if !rs.linesMatch("Twinkle,  twinkle, little star! ", "Twinkle, twinkle, little star!") {
+			t.Error("extra spaces should still be ignored")
+		}
+		if rs.linesMatch("twinkle, twinkle, little star!", "Twinkle, twinkle, little star!") {
+			t.Error("case should count")
+		}
+		if rs.linesMatch("Twinkle twinkle, little star!", "Twinkle, twinkle, little star!") {
+			t.Error("punctuation should count")
+		}
+		if rs.linesMatch("I'm gonna go", "I'm going to go") {
+			t.Error("variants should not be accepted")
+		}
+		if rs.linesMatch("stayin alive", "staying alive") {
+			t.Error("rules should not be applied")
+		}
+	})
+
+	t.Run("strict still accepts alternatives in the file", func(t *testing.T) {
+		if !strict(t).linesMatch("I'm gonna go", "I'm [going to|gonna] go") {
+			t.Error("declared alternative should match")
+		}
+	})
+
+	t.Run("strict diff highlights case and punctuation", func(t *testing.T) {
+		diff := strict(t).formatDiff("twinkle twinkle little star", "Twinkle, twinkle little star")
+		if !strings.Contains(diff, "twinkle") || !strings.Contains(diff, "(Twinkle,)") {
+			t.Errorf("diff should show the expected word, got %q", diff)
+		}
+		if strings.Contains(diff, "[Twinkle,]") {
+			t.Errorf("diff should treat the word as mistyped, not missing, got %q", diff)
+		}
+	})
+
+	t.Run("strict diff marks the differing characters", func(t *testing.T) {
+		var b strings.Builder
+		writeCharDiff(&b, "hello", "Hello,")
+		if got := b.String(); got != "hello(Hello,)" {
+			t.Errorf("writeCharDiff = %q, want %q", got, "hello(Hello,)")
+		}
+		in, ex := commonRunes([]rune("hello"), []rune("Hello,"))
+		if in[0] || !in[1] || ex[0] || ex[5] || !ex[4] {
+			t.Errorf("commonRunes = %v, %v, want the first letter and comma to differ", in, ex)
+		}
+	})
+
+	t.Run("strict scores exact words", func(t *testing.T) {
+		s := strict(t).scoreLine("twinkle, twinkle little star", "Twinkle, twinkle little star", 0)
+		if s.correct != 3 || s.words != 4 {
+			t.Errorf("score = %d/%d, want 3/4", s.correct, s.words)
+		}
+	})
+
+	t.Run("lenient forgives typos", func(t *testing.T) {
+		if !lenient(t).linesMatch("twinkle twinkle litle star", "twinkle twinkle little star") {
+			t.Error("typo should be forgiven")
+		}
+		if rules(t, matchConfig{Strictness: "lenient", Disable: []string{"typos"}}).linesMatch("twinkle twinkle litle star", "twinkle twinkle little star") {
+			t.Error("disabled typo rule should stay off")
+		}
+	})
+
+	t.Run("lenient forgives word order", func(t *testing.T) {
+		rs := lenient(t)
+		if !rs.linesMatch("little star twinkle twinkle", "Twinkle twinkle little star") {
+			t.Error("reordered words should match")
+		}
+		if rs.linesMatch("little star twinkle", "Twinkle twinkle little star") {
+			t.Error("a missing word should still count")
+		}
+		if s := rs.scoreLine("little star twinkle twinkle", "Twinkle twinkle little star", 0); s.correct != 4 {
+			t.Errorf("correct = %d, want 4", s.correct)
+		}
+		if diff := rs.formatDiff("little star twinkle twinkle", "Twinkle twinkle little star"); diff != "little star twinkle twinkle" {
+			t.Errorf("diff = %q, want every word right", diff)
+		}
+		if linesMatch("little star twinkle twinkle", "Twinkle twinkle little star") {
+			t.Error("normal should not forgive word order")
+		}
+	})
+
+	t.Run("unknown strictness is an error", func(t *testing.T) {
+		if _, err := (matchConfig{Strictness: "sloppy"}).ruleSet(""); err == nil {
+			t.Error("expected error for unknown strictness")
+		}
+	})
+}
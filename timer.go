@@ -0,0 +1,201 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// tickInterval is how often the running clock is updated in timed mode
+const tickInterval = 100 * time.Millisecond
+
+// timeLimits lists the per-line time limits cycled through on the mode
+// select screen. Zero means no limit.
+var timeLimits = []time.Duration{0, 5 * time.Second, 10 * time.Second, 15 * time.Second, 30 * time.Second, time.Minute}
+
+// tickMsg updates the running clock. Ticks from an earlier run are ignored
+// by comparing id with the model's tickID.
+type tickMsg struct {
+	id int
+	t  time.Time
+}
+
+// tick returns a command that sends a tickMsg after tickInterval
+func tick(id int) tea.Cmd {
+	return tea.Tick(tickInterval, func(t time.Time) tea.Msg {
+		return tickMsg{id: id, t: t}
+	})
+}
+
+// isTimed returns true if the run shows a clock, which is always the case
+// when there is a time limit or when singing along. Lines that are picked
+// rather than typed, and whole sections, are never timed.
+func (m model) isTimed() bool {
+	if m.mode.picksLines() || m.mode == modeSection {
+		return false
+	}
+	return m.timed || m.timeLimit > 0 || m.mode == modeSingAlong
+}
+
+// cycleTimeLimit switches to the next per-line time limit
+func (m *model) cycleTimeLimit() {
+	for i, limit := range timeLimits {
+		if limit == m.timeLimit {
+			m.timeLimit = timeLimits[(i+1)%len(timeLimits)]
+			return
+		}
+	}
+	m.timeLimit = timeLimits[0]
+}
+
+// startRun starts the clock for a new run and returns the first tick if
+// the run is timed
+func (m *model) startRun() tea.Cmd {
+	m.now = m.clock()
+	m.runStart = m.now
+	m.lineShown = m.now
+	m.lineStart = time.Time{}
+	m.tickID++
+	if !m.isTimed() || m.state != stateTyping {
+		return nil
+	}
+	return tick(m.tickID)
+}
+
+// handleTick updates the clock and submits the current line if its time
+// limit has run out
+func (m model) handleTick(msg tickMsg) (tea.Model, tea.Cmd) {
+	if msg.id != m.tickID || m.state != stateTyping {
+		return m, nil
+	}
+	m.now = msg.t
+
+	// When singing along, every line the song has moved past is missed
+	if m.mode == modeSingAlong {
+		for m.state == stateTyping && m.songPosition() >= m.lineDeadline(m.currentLine) {
+			m.timedOut[m.currentLine] = true
+			if cmd := m.submitLine(); m.state == stateResult {
+				return m, cmd
+			}
+		}
+		return m, tick(m.tickID)
+	}
+
+	if m.timeLimit > 0 && m.now.Sub(m.lineShown) >= m.timeLimit {
+		m.timedOut[m.currentLine] = true
+		if cmd := m.submitLine(); m.state == stateResult {
+			return m, cmd
+		}
+	}
+	return m, tick(m.tickID)
+}
+
+// recordKeystroke starts the latency timer on the first keystroke of a line
+func (m *model) recordKeystroke() {
+	if m.lineStart.IsZero() {
+		m.lineStart = m.clock()
+	}
+}
+
+// recordLatency stores the time from the first keystroke on the current
+// line until it was submitted, and restarts the clock for the next line
+func (m *model) recordLatency() {
+	now := m.clock()
+	if !m.lineStart.IsZero() {
+		m.latencies[m.currentLine] = now.Sub(m.lineStart)
+	}
+	m.lineStart = time.Time{}
+	m.lineShown = now
+	m.elapsed = now.Sub(m.runStart)
+}
+
+// wpm returns typing speed in words per minute over the run, using the
+// standard five characters per word
+func (m model) wpm() int {
+	if m.elapsed <= 0 {
+		return 0
+	}
+	chars := 0
+	for _, input := range m.userInputs {
+		chars += len([]rune(input))
+	}
+	return int(float64(chars) / 5 / m.elapsed.Minutes())
+}
+
+// averageLatency returns the average latency of typed lines
+func (m model) averageLatency() time.Duration {
+	var total time.Duration
+	var n int
+	for i, line := range m.lines {
+		if !isComment(line) && m.latencies[i] > 0 {
+			total += m.latencies[i]
+			n++
+		}
+	}
+	if n == 0 {
+		return 0
+	}
+	return total / time.Duration(n)
+}
+
+// formatDuration formats d as m:ss
+func formatDuration(d time.Duration) string {
+	d = d.Truncate(time.Second)
+	return fmt.Sprintf("%d:%02d", int(d.Minutes()), int(d.Seconds())%60)
+}
+
+// writeClock writes the running clock and time left on the current line
+func (m model) writeClock(b *strings.Builder) {
+	if !m.isTimed() {
+		return
+	}
+	if m.mode == modeSingAlong {
+		left := max(m.lineDeadline(m.currentLine)-m.songPosition(), 0)
+		b.WriteString(dimStyle.Render("♪ " + formatDuration(m.songPosition())))
+		b.WriteString(m.timeLeft(left))
+		b.WriteString("\n")
+		return
+	}
+
+	b.WriteString(dimStyle.Render("⏱ " + formatDuration(m.now.Sub(m.runStart))))
+	if m.timeLimit > 0 {
+		b.WriteString(m.timeLeft(max(m.timeLimit-m.now.Sub(m.lineShown), 0)))
+	}
+	b.WriteString("\n")
+}
+
+// timeLeft renders the time left on the current line, in red when it's
+// nearly up
+func (m model) timeLeft(left time.Duration) string {
+	style := dimStyle
+	if left <= 3*time.Second {
+		style = redStyle
+	}
+	return style.Render(fmt.Sprintf("  %ds left", int(left.Round(time.Second).Seconds())))
+}
+
+// writeTiming writes the elapsed time, typing speed and average latency
+// on the result screen
+func (m model) writeTiming(b *strings.Builder) {
+	if !m.isTimed() {
+		return
+	}
+	b.WriteString(fmt.Sprintf("Time: %s, %d WPM, %.1fs per line\n",
+		formatDuration(m.elapsed), m.wpm(), m.averageLatency().Seconds()))
+}
+
+// lineTiming returns the dimmed latency shown after a line on the result
+// screen, or a note that the line ran out of time
+func (m model) lineTiming(i int) string {
+	if !m.isTimed() {
+		return ""
+	}
+	if m.timedOut[i] && m.mode == modeSingAlong {
+		return " " + redStyle.Render("(missed)")
+	} else if m.timedOut[i] {
+		return " " + redStyle.Render("(time's up)")
+	}
+	return " " + dimStyle.Render(fmt.Sprintf("(%.1fs)", m.latencies[i].Seconds()))
+}
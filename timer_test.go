@@ -0,0 +1,186 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// fakeClock returns a clock function and a function that advances it
+func fakeClock() (func() time.Time, func(time.Duration)) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	return func() time.Time { return now }, func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestTimedMode(t *testing.T) {
+	t.Run("t toggles the timer on the mode select screen", func(t *testing.T) {
+		m := initialModel(metadata{}, []string{"Line one"})
+
+		newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'t'}})
+		m = newModel.(model)
+
+		if !m.timed {
+			t.Error("timer should be on")
+		}
+		if !strings.Contains(m.View(), "Timer: on") {
+			t.Error("view should show timer is on")
+		}
+	})
+
+	t.Run("l cycles the time limit", func(t *testing.T) {
+		m := initialModel(metadata{}, []string{"Line one"})
+
+		newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'l'}})
+		m = newModel.(model)
+		if m.timeLimit != 5*time.Second {
+			t.Errorf("timeLimit = %v, want 5s", m.timeLimit)
+		}
+
+		for range timeLimits[1:] {
+			m.cycleTimeLimit()
+		}
+		if m.timeLimit != 0 {
+			t.Errorf("timeLimit = %v, want to wrap around to none", m.timeLimit)
+		}
+	})
+
+	t.Run("starting a timed run ticks", func(t *testing.T) {
+		m := initialModel(metadata{}, []string{"Line one"})
+		m.timed = true
+		m.state = stateSectionSelect
+
+		_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'a'}})
+		if cmd == nil {
+			t.Error("expected tick command")
+		}
+	})
+
+	t.Run("starting an untimed run does not tick", func(t *testing.T) {
+		m := initialModel(metadata{}, []string{"Line one"})
+		m.state = stateSectionSelect
+
+		_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'a'}})
+		if cmd != nil {
+			t.Error("expected no command")
+		}
+	})
+
+	t.Run("records latency from first keystroke to enter", func(t *testing.T) {
+		clock, advance := fakeClock()
+		m := initialModel(metadata{}, []string{"Hi", "There"})
+		m.clock = clock
+		m.timed = true
+		m.state = stateTyping
+		m.startRun()
+
+		advance(5 * time.Second) // thinking time isn't latency
+		newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'H'}})
+		m = newModel.(model)
+		advance(2 * time.Second)
+		newModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'i'}})
+		m = newModel.(model)
+		newModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		m = newModel.(model)
+
+		if m.latencies[0] != 2*time.Second {
+			t.Errorf("latencies[0] = %v, want 2s", m.latencies[0])
+		}
+		if m.elapsed != 7*time.Second {
+			t.Errorf("elapsed = %v, want 7s", m.elapsed)
+		}
+	})
+
+	t.Run("time limit auto-submits the line", func(t *testing.T) {
+		clock, advance := fakeClock()
+		m := initialModel(metadata{}, []string{"Line one", "Line two"})
+		m.clock = clock
+		m.timeLimit = 5 * time.Second
+		m.state = stateTyping
+		m.startRun()
+		m.input.SetValue("Line")
+
+		advance(4 * time.Second)
+		newModel, cmd := m.Update(tickMsg{id: m.tickID, t: clock()})
+		m = newModel.(model)
+		if m.currentLine != 0 || cmd == nil {
+			t.Fatalf("currentLine = %d, want 0 and another tick", m.currentLine)
+		}
+
+		advance(time.Second)
+		newModel, _ = m.Update(tickMsg{id: m.tickID, t: clock()})
+		m = newModel.(model)
+		if m.currentLine != 1 {
+			t.Fatalf("currentLine = %d, want 1 after time limit", m.currentLine)
+		}
+		if !m.timedOut[0] || m.results[0] || m.userInputs[0] != "Line" {
+			t.Errorf("line should be submitted as timed out: timedOut=%v results=%v input=%q", m.timedOut[0], m.results[0], m.userInputs[0])
+		}
+	})
+
+	t.Run("ticks from a previous run are ignored", func(t *testing.T) {
+		m := initialModel(metadata{}, []string{"Line one"})
+		m.timed = true
+		m.state = stateTyping
+		m.startRun()
+		old := m.tickID
+		m.startRun()
+
+		_, cmd := m.Update(tickMsg{id: old, t: time.Now()})
+		if cmd != nil {
+			t.Error("stale tick should not schedule another tick")
+		}
+	})
+
+	t.Run("result screen shows time and WPM", func(t *testing.T) {
+		m := initialModel(metadata{}, []string{"Line one"})
+		m.timed = true
+		m.state = stateResult
+		m.currentLine = 1
+		m.userInputs[0] = strings.Repeat("x", 50) // 10 words
+		m.latencies[0] = 1500 * time.Millisecond
+		m.elapsed = 30 * time.Second
+
+		view := m.View()
+		if !strings.Contains(view, "Time: 0:30, 20 WPM, 1.5s per line") {
+			t.Errorf("view should show timing, got: %s", view)
+		}
+		if !strings.Contains(view, "(1.5s)") {
+			t.Errorf("view should show line latency, got: %s", view)
+		}
+	})
+
+	t.Run("typing view shows time left", func(t *testing.T) {
+		clock, advance := fakeClock()
+		m := initialModel(metadata{}, []string{"Line one"})
+		m.clock = clock
+		m.timeLimit = 10 * time.Second
+		m.state = stateTyping
+		m.startRun()
+		advance(4 * time.Second)
+		m.now = clock()
+
+		if view := m.View(); !strings.Contains(view, "6s left") {
+			t.Errorf("view should show time left, got: %s", view)
+		}
+	})
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{0, "0:00"},
+		{1500 * time.Millisecond, "0:01"},
+		{83 * time.Second, "1:23"},
+		{10 * time.Minute, "10:00"},
+	}
+
+	for _, tt := range tests {
+		if got := formatDuration(tt.d); got != tt.want {
+			t.Errorf("formatDuration(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}